@@ -4,7 +4,9 @@ go 1.20
 
 require (
 	github.com/go-mysql-org/go-mysql v1.7.0
+	github.com/google/uuid v1.3.0
 	github.com/meilisearch/meilisearch-go v0.26.0
+	github.com/pingcap/tidb/parser v0.0.0-20221126021158-6b02a5d8ba7d
 	github.com/startopsz/rule v0.0.13
 	go.uber.org/zap v1.18.1
 	google.golang.org/protobuf v1.31.0
@@ -15,13 +17,11 @@ require (
 	github.com/BurntSushi/toml v0.3.1 // indirect
 	github.com/andybalholm/brotli v1.0.4 // indirect
 	github.com/golang-jwt/jwt/v4 v4.5.0 // indirect
-	github.com/google/uuid v1.3.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/klauspost/compress v1.15.6 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/pingcap/errors v0.11.5-0.20210425183316-da1aaba5fb63 // indirect
 	github.com/pingcap/log v0.0.0-20210625125904-98ed8e2eb1c7 // indirect
-	github.com/pingcap/tidb/parser v0.0.0-20221126021158-6b02a5d8ba7d // indirect
 	github.com/shopspring/decimal v0.0.0-20180709203117-cd690d0c9e24 // indirect
 	github.com/siddontang/go v0.0.0-20180604090527-bdc77568d726 // indirect
 	github.com/siddontang/go-log v0.0.0-20180807004314-8d05993dda07 // indirect
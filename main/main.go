@@ -8,6 +8,7 @@ import (
 	"github.com/go-mysql-org/go-mysql/canal"
 	"github.com/go-mysql-org/go-mysql/mysql"
 	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"github.com/qx66/mysql-meilisearch/pkg/admin"
 	"github.com/qx66/mysql-meilisearch/pkg/meilisearch"
 	"github.com/qx66/mysql-meilisearch/pkg/mysqlReplica"
 	"github.com/startopsz/rule/pkg/os/filesystem"
@@ -15,9 +16,7 @@ import (
 	"gopkg.in/yaml.v3"
 	"io"
 	"os"
-	"path/filepath"
-	"strconv"
-	"strings"
+	"time"
 )
 
 var configPath string
@@ -72,6 +71,19 @@ func main() {
 	config.Addr = fmt.Sprintf("%s:%d", bootstrap.Mysql.Host, bootstrap.Mysql.Port)
 	config.User = bootstrap.Mysql.User
 	config.Password = bootstrap.Mysql.Passwd
+	// TIMESTAMP 按 UTC 格式化, 与全量读取一致
+	config.TimestampStringLocation = time.UTC
+	if bootstrap.Mysql.Flavor != "" {
+		config.Flavor = bootstrap.Mysql.Flavor
+	}
+	
+	if config.Flavor != mysql.MySQLFlavor && config.Flavor != mysql.MariaDBFlavor {
+		logger.Error(
+			"不支持的 MySQL flavor",
+			zap.String("flavor", config.Flavor),
+		)
+		return
+	}
 	
 	c, err := canal.NewCanal(config)
 	if err != nil {
@@ -87,7 +99,19 @@ func main() {
 	defer cancel()
 	
 	//
-	if !filesystem.Exists(bootstrap.Mysql.BinlogCheckpointDir) {
+	meiliSearchClient := meilisearch.NewClient(bootstrap.Meilisearch.Host, bootstrap.Meilisearch.Apikey, logger)
+	
+	//
+	checkpointStore, err := mysqlReplica.NewCheckpointStore(&bootstrap, meiliSearchClient, logger)
+	if err != nil {
+		logger.Error(
+			"创建 checkpoint 存储失败",
+			zap.Error(err),
+		)
+		return
+	}
+	
+	if _, ok := checkpointStore.(*mysqlReplica.FileCheckpointStore); ok && !filesystem.Exists(bootstrap.Mysql.BinlogCheckpointDir) {
 		err = os.Mkdir(bootstrap.Mysql.BinlogCheckpointDir, 0750)
 		if err != nil {
 			logger.Error(
@@ -98,9 +122,7 @@ func main() {
 		}
 	}
 	
-	//
-	meiliSearchClient := meilisearch.NewClient(bootstrap.Meilisearch.Host, bootstrap.Meilisearch.Apikey, logger)
-	eventHandler := mysqlReplica.NewEventHandler(ctx, meiliSearchClient, bootstrap.Sync, bootstrap.Mysql.BinlogCheckpointDir, logger)
+	eventHandler := mysqlReplica.NewEventHandler(ctx, meiliSearchClient, bootstrap.Sync, checkpointStore, logger)
 	
 	// Meilisearch - 初始化 & 校验 Meilisearch 信息
 	err = eventHandler.UpdateAttributes()
@@ -114,91 +136,190 @@ func main() {
 	}
 	
 	eventHandler.SetCancel(cancel)
-	eventHandler.SetPosChannel(make(chan mysql.Position, 4096))
+	eventHandler.SetCanal(c, config)
+	eventHandler.SetSnapshotConf(bootstrap.Snapshot)
+	eventHandler.SetPosChannel(make(chan mysqlReplica.PendingCheckpoint, 4096))
+	eventHandler.SetGTIDMode(bootstrap.Mysql.GtidMode)
 	
 	//c.Execute()
 	
 	go eventHandler.SavePos()
 	
+	// Meilisearch 任务失败时 SavePos 会取消 ctx, 此时需要停止 canal
+	go func() {
+		<-ctx.Done()
+		c.Close()
+	}()
+	
 	c.SetEventHandler(eventHandler)
 	
 	//
-	binlogPosCheckpointFile := filepath.Join(bootstrap.Mysql.BinlogCheckpointDir, "checkpoint")
-	var startPosition mysql.Position
+	checkpoint, err := checkpointStore.Load()
+	if err != nil {
+		logger.Error(
+			"读取 checkpoint 失败",
+			zap.String("checkpointStore", checkpointStore.String()),
+			zap.Error(err),
+		)
+		return
+	}
+	
+	serverUUID, err := mysqlReplica.ServerUUID(c, config.Flavor)
+	if err != nil {
+		logger.Error(
+			"获取 MySQL server uuid 失败",
+			zap.Error(err),
+		)
+		return
+	}
+	
+	firstRun := checkpoint == nil
+	snapshotPending := firstRun || checkpoint.SnapshotPending
+	if firstRun {
+		checkpoint = mysqlReplica.NewCheckpoint(config.Flavor, serverUUID)
+	} else {
+		// binlog 文件名/位置只在同一个 MySQL 实例上有效, 主库切换后只能通过 GTID 恢复
+		if checkpoint.ServerUUID != "" && checkpoint.ServerUUID != serverUUID && !bootstrap.Mysql.GtidMode {
+			logger.Error(
+				"MySQL 实例与 checkpoint 不一致, binlog 位置不可用, 请开启 gtidMode 或删除 checkpoint 重新同步",
+				zap.String("checkpointServerUUID", checkpoint.ServerUUID),
+				zap.String("serverUUID", serverUUID),
+			)
+			return
+		}
+		
+		checkpoint.Flavor = config.Flavor
+		checkpoint.ServerUUID = serverUUID
+	}
 	
-	if filesystem.Exists(binlogPosCheckpointFile) {
-		f, err := os.Open(binlogPosCheckpointFile)
+	if snapshotPending {
+		// 第一次运行按配置的方式获取全量同步起始位置;
+		// 上一次全量同步中途退出时沿用原来的起始位置, 从最后完成的一批继续, 不需要一致性快照
+		snapshotMode := bootstrap.GetSnapshot().GetMode()
+		if !firstRun {
+			snapshotMode = mysqlReplica.SnapshotModeNone
+			logger.Info(
+				"继续上一次未完成的全量同步",
+				zap.String("Position", checkpoint.Position().String()),
+				zap.String("GTIDSet", checkpoint.GTIDSet),
+			)
+		}
+		
+		// 全量同步使用单独的连接, 每个 worker 一个
+		snapshot, err := mysqlReplica.OpenSnapshot(config.Addr, config.User, config.Password, config.Flavor, snapshotMode, int(bootstrap.GetSnapshot().GetConcurrency()), logger)
 		if err != nil {
 			logger.Error(
-				"打开 MySQL Binlog Pos CheckPoint 文件失败",
+				"开启全量同步连接失败",
+				zap.String("mode", snapshotMode),
 				zap.Error(err),
 			)
 			return
 		}
-		checkpointByte, err := io.ReadAll(f)
+		
+		if firstRun {
+			checkpoint.SetPosition(snapshot.Position)
+			// GTID 模式, 主库切换后依然可以通过 GTIDSet 找到同步位置
+			if bootstrap.Mysql.GtidMode {
+				if snapshot.GTIDSet == "" {
+					snapshot.Close()
+					logger.Error("已配置 gtidMode, 但 MySQL 没有返回 GTIDSet, 请检查是否开启 gtid_mode")
+					return
+				}
+				checkpoint.GTIDSet = snapshot.GTIDSet
+			}
+			
+			logger.Info(
+				"获取全量同步起始位置",
+				zap.String("Position", checkpoint.Position().String()),
+				zap.String("GTIDSet", checkpoint.GTIDSet),
+			)
+		}
+		
+		err = eventHandler.SetCheckpoint(checkpoint)
 		if err != nil {
 			logger.Error(
-				"读取 MySQL Binlog Pos CheckPoint 文件内容失败",
+				"按 checkpoint 更新同步配置失败",
 				zap.Error(err),
 			)
 			return
 		}
 		
-		checkpoints := strings.Split(string(checkpointByte), " ")
-		if len(checkpoints) != 2 {
+		err = eventHandler.FirstInitTable(c, snapshot.Executors())
+		closeErr := snapshot.Close()
+		if closeErr != nil {
+			logger.Warn(
+				"关闭全量同步连接失败",
+				zap.Error(closeErr),
+			)
+		}
+		if err != nil {
 			logger.Error(
-				"MySQL Binlog Pos CheckPoint 文件格式不符合规范",
+				"全量表数据同步失败",
 				zap.Error(err),
 			)
 			return
 		}
 		
-		posNumber, err := strconv.ParseUint(checkpoints[1], 10, 64)
+		err = eventHandler.FlushCheckpoint()
 		if err != nil {
 			logger.Error(
-				"MySQL Binlog Pos CheckPoint PosNumber异常",
+				"全量同步完成, 写入 checkpoint 失败",
 				zap.Error(err),
 			)
 			return
 		}
-		
-		startPosition.Name = checkpoints[0]
-		startPosition.Pos = uint32(posNumber)
-		
-		logger.Info(
-			"从 checkpoint 文件中读取 Position",
-			zap.String("Position", startPosition.String()),
-		)
 	} else {
-		pos, err := c.GetMasterPos()
+		err = eventHandler.SetCheckpoint(checkpoint)
 		if err != nil {
 			logger.Error(
-				"获取Master Pos失败",
+				"按 checkpoint 更新同步配置失败",
 				zap.Error(err),
 			)
 			return
 		}
-		
-		startPosition = pos
-		
-		logger.Info(
-			"通过GetMasterPos获取Position",
-			zap.String("Position", startPosition.String()),
+	}
+	
+	// 新加入同步配置的表以及未完成的 backfill, 与 binlog 同步同时进行
+	err = eventHandler.StartBackfills()
+	if err != nil {
+		logger.Error(
+			"启动 backfill 失败",
+			zap.Error(err),
 		)
-		
-		err = eventHandler.FirstInitTable(c)
+		return
+	}
+	
+	// 管理接口, 全量同步完成之后启动
+	if addr := bootstrap.GetAdmin().GetAddr(); addr != "" {
+		adminServer := admin.NewServer(addr, eventHandler, logger)
+		adminServer.Start()
+		defer adminServer.Shutdown(context.Background())
+	}
+	
+	//
+	if bootstrap.Mysql.GtidMode && checkpoint.GTIDSet != "" {
+		startGTIDSet, err := mysql.ParseGTIDSet(config.Flavor, checkpoint.GTIDSet)
 		if err != nil {
 			logger.Error(
-				"第一次运行，全量表数据同步失败",
+				"checkpoint GTIDSet 格式不符合规范",
+				zap.String("GTIDSet", checkpoint.GTIDSet),
 				zap.Error(err),
 			)
 			return
 		}
+		
+		err = c.StartFromGTID(startGTIDSet)
+	} else {
+		if bootstrap.Mysql.GtidMode {
+			logger.Warn(
+				"checkpoint 中没有 GTIDSet, 使用 binlog 位置启动",
+				zap.String("Position", checkpoint.Position().String()),
+			)
+		}
+		
+		err = c.RunFrom(checkpoint.Position())
 	}
 	
-	//
-	err = c.RunFrom(startPosition)
-	
 	if err != nil {
 		logger.Error(
 			"启动程序失败",
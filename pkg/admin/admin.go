@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// Handler 在不停止同步的情况下重建 index 或重新全量同步一张表
+
+type Handler interface {
+	Resync(index string) error
+	Backfill(db, table string) error
+}
+
+// Server 管理接口
+// POST /resync?index=<index> 重建 index
+// POST /backfill?db=<db>&table=<table> 重新全量同步一张表
+
+type Server struct {
+	server  *http.Server
+	handler Handler
+	logger  *zap.Logger
+}
+
+type response struct {
+	Message string `json:"message"`
+}
+
+func NewServer(addr string, handler Handler, logger *zap.Logger) *Server {
+	server := &Server{
+		handler: handler,
+		logger:  logger,
+	}
+	
+	mux := http.NewServeMux()
+	mux.HandleFunc("/resync", server.resync)
+	mux.HandleFunc("/backfill", server.backfill)
+	
+	server.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server
+}
+
+// Start 在后台监听管理接口
+
+func (server *Server) Start() {
+	server.logger.Info(
+		"启动管理接口",
+		zap.String("addr", server.server.Addr),
+	)
+	
+	go func() {
+		err := server.server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			server.logger.Error(
+				"管理接口退出",
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+func (server *Server) Shutdown(ctx context.Context) error {
+	return server.server.Shutdown(ctx)
+}
+
+func (server *Server) resync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		server.reply(w, http.StatusMethodNotAllowed, "只支持 POST")
+		return
+	}
+	
+	index := r.URL.Query().Get("index")
+	if index == "" {
+		server.reply(w, http.StatusBadRequest, "缺少 index 参数")
+		return
+	}
+	
+	err := server.handler.Resync(index)
+	if err != nil {
+		server.logger.Warn(
+			"resync 请求失败",
+			zap.String("index", index),
+			zap.Error(err),
+		)
+		server.reply(w, http.StatusConflict, err.Error())
+		return
+	}
+	
+	server.reply(w, http.StatusAccepted, "resync 已开始")
+}
+
+func (server *Server) backfill(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		server.reply(w, http.StatusMethodNotAllowed, "只支持 POST")
+		return
+	}
+	
+	db := r.URL.Query().Get("db")
+	table := r.URL.Query().Get("table")
+	if db == "" || table == "" {
+		server.reply(w, http.StatusBadRequest, "缺少 db 或 table 参数")
+		return
+	}
+	
+	err := server.handler.Backfill(db, table)
+	if err != nil {
+		server.logger.Warn(
+			"backfill 请求失败",
+			zap.String("database", db),
+			zap.String("table", table),
+			zap.Error(err),
+		)
+		server.reply(w, http.StatusConflict, err.Error())
+		return
+	}
+	
+	server.reply(w, http.StatusAccepted, "backfill 已开始")
+}
+
+func (server *Server) reply(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response{Message: message})
+}
@@ -1,14 +1,43 @@
 package meilisearch
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/meilisearch/meilisearch-go"
 	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// 同步等待单个任务的超时时间
+	waitTaskTimeout = 30 * time.Second
+	// index 不存在的错误码
+	indexNotFoundCode = "index_not_found"
+	// 由调用方写入向量的 embedder
+	EmbedderSourceUserProvided = "userProvided"
 )
 
 type Client struct {
-	client *meilisearch.Client
-	logger *zap.Logger
+	client  *meilisearch.Client
+	tracker *TaskTracker
+	logger  *zap.Logger
+	// meilisearch-go 不支持的接口直接通过 HTTP 调用
+	host   string
+	apiKey string
+}
+
+// Embedder index 的 embedder 设置
+
+type Embedder struct {
+	Source     string `json:"source"`
+	Dimensions int    `json:"dimensions,omitempty"`
 }
 
 func NewClient(host, apiKey string, logger *zap.Logger) *Client {
@@ -20,11 +49,28 @@ func NewClient(host, apiKey string, logger *zap.Logger) *Client {
 	)
 	
 	return &Client{
-		client: client,
-		logger: logger,
+		client:  client,
+		tracker: NewTaskTracker(client, logger),
+		logger:  logger,
+		host:    strings.TrimSuffix(host, "/"),
+		apiKey:  apiKey,
 	}
 }
 
+// Tracker 文档增删改提交的任务都会记录在 TaskTracker 中
+
+func (client *Client) Tracker() *TaskTracker {
+	return client.tracker
+}
+
+// Isolated 返回使用单独 TaskTracker 的 Client, 通过它提交的任务不会出现在原 Client 的 TaskTracker 中
+
+func (client *Client) Isolated() *Client {
+	isolated := *client
+	isolated.tracker = NewTaskTracker(client.client, client.logger)
+	return &isolated
+}
+
 func (client *Client) CreateIndex(indexUid, primaryKey string) error {
 	//_, err := client.client.GetIndex(indexUid)
 	//if err != nil {
@@ -68,6 +114,102 @@ func (client *Client) UpdateAttributes(indexName string, filterAbleField ...stri
 	return err
 }
 
+// GetSortableAttributes 获取 index 当前的 sortable 属性, index 不存在时返回空
+
+func (client *Client) GetSortableAttributes(indexName string) ([]string, error) {
+	index := client.client.Index(indexName)
+	sortableField, err := index.GetSortableAttributes()
+	
+	var apiErr *meilisearch.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil || sortableField == nil {
+		return nil, err
+	}
+	return *sortableField, nil
+}
+
+// UpdateSortableAttributes 更新 sortable 属性, 覆盖原有设置
+
+func (client *Client) UpdateSortableAttributes(indexName string, sortableField ...string) error {
+	index := client.client.Index(indexName)
+	task, err := index.UpdateSortableAttributes(&sortableField)
+	if err != nil {
+		client.logger.Error("更新meilisearch sortable属性失败",
+			zap.String("indexUid", indexName),
+			zap.Error(err),
+		)
+		return err
+	}
+	
+	client.logger.Debug("更新meilisearch sortable属性成功",
+		zap.String("status", string(task.Status)),
+		zap.String("indexUid", task.IndexUID),
+		zap.Int64("taskUid", task.TaskUID),
+	)
+	return nil
+}
+
+// UpdateEmbedders 更新 index 的 embedder 设置并等待任务执行成功, 只修改传入的 embedder
+// Meilisearch v1.13 之前需要开启实验特性 vectorStore
+
+func (client *Client) UpdateEmbedders(indexName string, embedders map[string]Embedder) error {
+	body, err := json.Marshal(embedders)
+	if err != nil {
+		return err
+	}
+	
+	// 请求与等待任务共用超时时间, 避免 Meilisearch 无响应时一直阻塞
+	ctx, cancel := context.WithTimeout(context.Background(), waitTaskTimeout)
+	defer cancel()
+	
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf("%s/indexes/%s/settings/embedders", client.host, url.PathEscape(indexName)), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+client.apiKey)
+	}
+	
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		client.logger.Error("更新meilisearch embedder设置失败",
+			zap.String("indexUid", indexName),
+			zap.Int("statusCode", resp.StatusCode),
+			zap.ByteString("response", respBody),
+		)
+		return fmt.Errorf("更新 index %s embedder 设置失败, status: %d, response: %s", indexName, resp.StatusCode, respBody)
+	}
+	
+	var task meilisearch.TaskInfo
+	err = json.Unmarshal(respBody, &task)
+	if err != nil {
+		return err
+	}
+	
+	_, err = client.waitTask(ctx, task.TaskUID)
+	if err != nil {
+		return err
+	}
+	
+	client.logger.Debug("更新meilisearch embedder设置成功",
+		zap.String("indexUid", indexName),
+		zap.Int64("taskUid", task.TaskUID),
+	)
+	return nil
+}
+
 func (client *Client) Search(indexName string, keyWord string, page int64) {
 	index := client.client.Index(indexName)
 	res, err := index.Search(keyWord, &meilisearch.SearchRequest{
@@ -100,6 +242,7 @@ func (client *Client) CreateDocs(indexName string, docs interface{}, primaryKey
 		return err
 	}
 	
+	client.tracker.Track(task)
 	client.logger.Debug("调用添加文档成功",
 		zap.String("status", string(task.Status)),
 		zap.String("indexUid", task.IndexUID),
@@ -113,12 +256,143 @@ func (client *Client) CreateDocs(indexName string, docs interface{}, primaryKey
 
 func (client *Client) UpdateDocuments(indexName string, primaryKey string, document interface{}) error {
 	index := client.client.Index(indexName)
-	_, err := index.UpdateDocuments(document, primaryKey)
-	return err
+	task, err := index.UpdateDocuments(document, primaryKey)
+	if err != nil {
+		return err
+	}
+	
+	client.tracker.Track(task)
+	return nil
 }
 
 func (client *Client) DeleteDocument(indexName, identifier string) error {
 	index := client.client.Index(indexName)
-	_, err := index.DeleteDocument(identifier)
+	task, err := index.DeleteDocument(identifier)
+	if err != nil {
+		return err
+	}
+	
+	client.tracker.Track(task)
+	return nil
+}
+
+func (client *Client) DeleteDocuments(indexName string, identifiers []string) error {
+	index := client.client.Index(indexName)
+	task, err := index.DeleteDocuments(identifiers)
+	if err != nil {
+		return err
+	}
+	
+	client.tracker.Track(task)
+	return nil
+}
+
+// DeleteAllDocuments 删除 index 中的所有文档
+
+func (client *Client) DeleteAllDocuments(indexName string) error {
+	index := client.client.Index(indexName)
+	task, err := index.DeleteAllDocuments()
+	if err != nil {
+		return err
+	}
+	
+	client.tracker.Track(task)
+	return nil
+}
+
+// DeleteDocumentsByFilter 删除匹配 filter 的文档, filter 中的字段需要设置为 filterable
+
+func (client *Client) DeleteDocumentsByFilter(indexName string, filter string) error {
+	index := client.client.Index(indexName)
+	task, err := index.DeleteDocumentsByFilter(filter)
+	if err != nil {
+		return err
+	}
+	
+	client.tracker.Track(task)
+	return nil
+}
+
+// GetDocument 获取单个文档, 文档或 index 不存在时返回 false
+
+func (client *Client) GetDocument(indexName, identifier string, document interface{}) (bool, error) {
+	index := client.client.Index(indexName)
+	err := index.GetDocument(identifier, nil, document)
+	
+	var apiErr *meilisearch.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SaveDocument 写入单个文档并等待任务执行成功, 不记录到 TaskTracker 中
+
+func (client *Client) SaveDocument(indexName, primaryKey string, document interface{}) error {
+	index := client.client.Index(indexName)
+	task, err := index.AddDocuments([]interface{}{document}, primaryKey)
+	if err != nil {
+		return err
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), waitTaskTimeout)
+	defer cancel()
+	
+	_, err = client.waitTask(ctx, task.TaskUID)
+	return err
+}
+
+// DeleteIndex 删除 index 并等待任务执行完成, index 不存在时忽略
+
+func (client *Client) DeleteIndex(ctx context.Context, indexUid string) error {
+	task, err := client.client.DeleteIndex(indexUid)
+	if err != nil {
+		return err
+	}
+	
+	t, err := client.waitTask(ctx, task.TaskUID)
+	if t != nil && t.Error.Code == indexNotFoundCode {
+		return nil
+	}
 	return err
 }
+
+// SwapIndexes 提交原子交换两个 index 的任务, 返回任务 id
+// 交换任务之前提交的任务都会先执行完成, 之后提交的任务作用于交换后的 index
+
+func (client *Client) SwapIndexes(indexA, indexB string) (int64, error) {
+	task, err := client.client.SwapIndexes([]meilisearch.SwapIndexesParams{
+		{Indexes: []string{indexA, indexB}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return task.TaskUID, nil
+}
+
+// WaitTask 等待任务执行成功
+
+func (client *Client) WaitTask(ctx context.Context, taskUID int64) error {
+	_, err := client.waitTask(ctx, taskUID)
+	return err
+}
+
+// waitTask 等待任务执行完成, 任务失败时同时返回任务信息与错误
+
+func (client *Client) waitTask(ctx context.Context, taskUID int64) (*meilisearch.Task, error) {
+	t, err := client.client.WaitForTask(taskUID, meilisearch.WaitParams{
+		Context:  ctx,
+		Interval: taskPollInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	if t.Status != meilisearch.TaskStatusSucceeded {
+		return t, fmt.Errorf("meilisearch 任务 %d 执行失败, status: %s, error: %s", t.TaskUID, t.Status, t.Error.Message)
+	}
+	return t, nil
+}
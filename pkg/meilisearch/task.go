@@ -0,0 +1,82 @@
+package meilisearch
+
+import (
+	"context"
+	"fmt"
+	"github.com/meilisearch/meilisearch-go"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// 轮询 Meilisearch 任务状态的间隔
+
+const taskPollInterval = 50 * time.Millisecond
+
+// TaskTracker 记录已提交但尚未确认的 Meilisearch 异步任务
+// 文档的增删改只是把任务放入 Meilisearch 队列, 只有任务状态为 succeeded 时数据才真正写入 index
+
+type TaskTracker struct {
+	sync.Mutex
+	client  *meilisearch.Client
+	logger  *zap.Logger
+	pending []int64
+}
+
+func NewTaskTracker(client *meilisearch.Client, logger *zap.Logger) *TaskTracker {
+	return &TaskTracker{
+		client: client,
+		logger: logger,
+	}
+}
+
+// Track 记录一个已提交的任务
+
+func (tracker *TaskTracker) Track(task *meilisearch.TaskInfo) {
+	if task == nil {
+		return
+	}
+	
+	tracker.Lock()
+	defer tracker.Unlock()
+	
+	tracker.pending = append(tracker.pending, task.TaskUID)
+}
+
+// Take 取出自上次调用以来提交的所有任务
+
+func (tracker *TaskTracker) Take() []int64 {
+	tracker.Lock()
+	defer tracker.Unlock()
+	
+	taskUIDs := tracker.pending
+	tracker.pending = nil
+	return taskUIDs
+}
+
+// Wait 等待任务全部执行完成, 任意一个任务失败或被取消时返回错误
+
+func (tracker *TaskTracker) Wait(ctx context.Context, taskUIDs []int64) error {
+	for _, taskUID := range taskUIDs {
+		task, err := tracker.client.WaitForTask(taskUID, meilisearch.WaitParams{
+			Context:  ctx,
+			Interval: taskPollInterval,
+		})
+		if err != nil {
+			return err
+		}
+		
+		if task.Status != meilisearch.TaskStatusSucceeded {
+			tracker.logger.Error("meilisearch 任务执行失败",
+				zap.Int64("taskUid", taskUID),
+				zap.String("status", string(task.Status)),
+				zap.String("indexUid", task.IndexUID),
+				zap.String("type", string(task.Type)),
+				zap.String("errorCode", task.Error.Code),
+				zap.String("errorMessage", task.Error.Message),
+			)
+			return fmt.Errorf("meilisearch 任务 %d 执行失败, status: %s, error: %s", taskUID, task.Status, task.Error.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,444 @@
+package mysqlReplica
+
+import (
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/canal"
+	"github.com/google/uuid"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// watermark 表默认配置
+
+const (
+	defaultWatermarkSchema = "mysql_meilisearch"
+	defaultWatermarkTable  = "watermark"
+)
+
+// watermark 类型
+
+const (
+	watermarkLow  = "low"
+	watermarkHigh = "high"
+)
+
+// backfillJob 一张表的增量全量同步 (DBLog watermark 算法), 与 binlog 同步交替执行, 不锁表
+// 每一批数据:
+// 1. 写入 low watermark
+// 2. 按主键顺序读取一批数据
+// 3. 写入 high watermark
+// binlog 中处理到 low watermark 之后, 记录变更过的文档 id; 处理到 high watermark 时,
+// 丢弃这批数据中变更过的行 (binlog 中的变更更新), 其余的行写入 Meilisearch
+
+type backfillJob struct {
+	s      *conf.Sync
+	key    string
+	window *watermarkWindow
+}
+
+// watermarkWindow 一批数据对应的 low/high watermark 窗口
+
+type watermarkWindow struct {
+	id   string
+	job  *backfillJob
+	open bool
+	seen map[string]bool
+	// 窗口内表被 TRUNCATE, 读取到的数据全部丢弃
+	truncated bool
+	docs      []map[string]interface{}
+	progress  *TableSnapshot
+	done      chan struct{}
+}
+
+// observe 记录窗口内 binlog 变更过的文档 id, 调用时需要持有 resyncLock
+
+func (window *watermarkWindow) observe(identifier interface{}) {
+	if window == nil || !window.open {
+		return
+	}
+	window.seen[identifierString(identifier)] = true
+}
+
+// watermarkTable 返回 watermark 表所在的库与表名
+
+func (eventHandler *EventHandler) watermarkTable() (string, string) {
+	schema := eventHandler.snapshotConf.GetWatermarkSchema()
+	if schema == "" {
+		schema = defaultWatermarkSchema
+	}
+	table := eventHandler.snapshotConf.GetWatermarkTable()
+	if table == "" {
+		table = defaultWatermarkTable
+	}
+	return schema, table
+}
+
+func (eventHandler *EventHandler) isWatermarkTable(schema, table string) bool {
+	watermarkSchema, watermarkTable := eventHandler.watermarkTable()
+	return schema == watermarkSchema && table == watermarkTable
+}
+
+// Backfill 在不停止 binlog 同步、不锁表的情况下重新全量同步一张表, 任务在后台执行
+
+func (eventHandler *EventHandler) Backfill(db, table string) error {
+	hit := eventHandler.lookupSync(db, table)
+	if hit == nil {
+		return fmt.Errorf("%s.%s 不在同步配置中", db, table)
+	}
+	
+	return eventHandler.startBackfill(hit, true)
+}
+
+// lookupSync 按库表名查找同步配置
+// 表改名时 binlog 协程会修改 Db / Table, 其它协程需要持有 resyncLock 读取
+
+func (eventHandler *EventHandler) lookupSync(db, table string) *conf.Sync {
+	eventHandler.resyncLock.Lock()
+	defer eventHandler.resyncLock.Unlock()
+	
+	for _, s := range eventHandler.sync {
+		if s.Db == db && s.Table == table {
+			return s
+		}
+	}
+	return nil
+}
+
+// StartBackfills 启动时为新加入同步配置的表, 以及上一次未完成 backfill 的表启动增量全量同步
+// 旧版本 checkpoint 中没有表的同步状态, 认为所有表都已经同步完成
+
+func (eventHandler *EventHandler) StartBackfills() error {
+	eventHandler.Lock()
+	legacy := len(eventHandler.checkpoint.Tables) == 0
+	var pending []*conf.Sync
+	for _, s := range eventHandler.sync {
+		key := fmt.Sprintf("%s.%s", s.Db, s.Table)
+		snapshot, ok := eventHandler.checkpoint.Tables[key]
+		switch {
+		case legacy:
+			snapshot = eventHandler.checkpoint.Table(s.Db, s.Table)
+			snapshot.Done = true
+			snapshot.UpdatedAt = time.Now()
+		case !ok || snapshot.Backfill && !snapshot.Done:
+			pending = append(pending, s)
+		}
+	}
+	eventHandler.Unlock()
+	
+	for _, s := range pending {
+		err := eventHandler.startBackfill(s, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startBackfill reset 为 true 时从头开始, 否则从 checkpoint 中记录的 LastKey 继续
+
+func (eventHandler *EventHandler) startBackfill(s *conf.Sync, reset bool) error {
+	if eventHandler.canal == nil {
+		return fmt.Errorf("canal 未初始化, 无法 backfill")
+	}
+	
+	eventHandler.resyncLock.Lock()
+	db, table := s.Db, s.Table
+	job := &backfillJob{
+		s:   s,
+		key: fmt.Sprintf("%s.%s", db, table),
+	}
+	if _, ok := eventHandler.backfills[job.key]; ok {
+		eventHandler.resyncLock.Unlock()
+		return fmt.Errorf("%s 正在 backfill", job.key)
+	}
+	eventHandler.backfills[job.key] = job
+	eventHandler.resyncLock.Unlock()
+	
+	eventHandler.Lock()
+	snapshot := eventHandler.checkpoint.Table(db, table)
+	if reset || !snapshot.Backfill {
+		*snapshot = TableSnapshot{}
+	}
+	snapshot.Done = false
+	snapshot.Backfill = true
+	snapshot.UpdatedAt = time.Now()
+	eventHandler.Unlock()
+	
+	go eventHandler.backfill(job)
+	return nil
+}
+
+func (eventHandler *EventHandler) backfill(job *backfillJob) {
+	startTime := time.Now()
+	eventHandler.logger.Info(
+		"开始 backfill",
+		zap.String("database", job.s.Db),
+		zap.String("table", job.s.Table),
+	)
+	
+	err := eventHandler.runBackfill(job)
+	
+	eventHandler.resyncLock.Lock()
+	delete(eventHandler.backfills, job.key)
+	if job.window != nil {
+		delete(eventHandler.windows, job.window.id)
+	}
+	eventHandler.resyncLock.Unlock()
+	
+	if err != nil {
+		eventHandler.logger.Error(
+			"backfill 失败",
+			zap.String("database", job.s.Db),
+			zap.String("table", job.s.Table),
+			zap.Error(err),
+		)
+		return
+	}
+	
+	eventHandler.logger.Info(
+		"backfill 成功",
+		zap.String("database", job.s.Db),
+		zap.String("table", job.s.Table),
+		zap.Duration("elapsed", time.Since(startTime)),
+	)
+}
+
+func (eventHandler *EventHandler) runBackfill(job *backfillJob) error {
+	s := job.s
+	config := eventHandler.canalConfig
+	snapshot, err := OpenSnapshot(config.Addr, config.User, config.Password, config.Flavor, SnapshotModeNone, 1, eventHandler.logger)
+	if err != nil {
+		return err
+	}
+	defer snapshot.Close()
+	
+	executor := snapshot.Executors()[0]
+	err = eventHandler.createWatermarkTable(executor)
+	if err != nil {
+		return err
+	}
+	
+	table, err := eventHandler.canal.GetTable(s.Db, s.Table)
+	if err != nil {
+		return err
+	}
+	
+	chunker, err := newTableChunker(table, s, int(eventHandler.snapshotConf.GetChunkSize()))
+	if err != nil {
+		return err
+	}
+	
+	eventHandler.RLock()
+	progress := *eventHandler.checkpoint.Table(s.Db, s.Table)
+	eventHandler.RUnlock()
+	
+	rows := progress.Rows
+	if len(progress.LastKey) > 0 && !chunker.Resume(progress.LastKey) {
+		eventHandler.logger.Warn(
+			"分页列与上一次不一致, 重新 backfill",
+			zap.String("database", s.Db),
+			zap.String("table", s.Table),
+			zap.Strings("lastKey", progress.LastKey),
+		)
+		rows = 0
+	}
+	
+	for {
+		window := &watermarkWindow{
+			id:   uuid.NewString(),
+			job:  job,
+			seen: make(map[string]bool),
+			done: make(chan struct{}),
+		}
+		
+		eventHandler.resyncLock.Lock()
+		eventHandler.windows[window.id] = window
+		job.window = window
+		eventHandler.resyncLock.Unlock()
+		
+		err = eventHandler.writeWatermark(executor, job.key, window.id, watermarkLow)
+		if err != nil {
+			return err
+		}
+		
+		r, err := executor.Execute(chunker.Query())
+		if err != nil {
+			return err
+		}
+		
+		rowValues := make([][]interface{}, 0, len(r.Values))
+		for _, v := range r.Values {
+			row := make([]interface{}, len(v))
+			for n, x := range v {
+				row[n] = x.Value()
+			}
+			rowValues = append(rowValues, row)
+		}
+		
+		// 子表在写入 high watermark 之前读取, 窗口内子表的变更以 binlog 为准
+		docs, err := eventHandler.buildDocs(executor, chunker.columns, rowValues, s)
+		if err != nil {
+			return err
+		}
+		
+		more := chunker.Advance(r)
+		rows += int64(len(docs))
+		
+		eventHandler.resyncLock.Lock()
+		window.docs = docs
+		window.progress = &TableSnapshot{
+			Done:      !more,
+			Backfill:  more,
+			Rows:      rows,
+			UpdatedAt: time.Now(),
+		}
+		if more {
+			window.progress.LastKey = chunker.LastKey()
+		}
+		eventHandler.resyncLock.Unlock()
+		
+		err = eventHandler.writeWatermark(executor, job.key, window.id, watermarkHigh)
+		if err != nil {
+			return err
+		}
+		
+		// 等待 binlog 处理到 high watermark, 这批数据写入之后再读取下一批
+		select {
+		case <-window.done:
+		case <-eventHandler.ctx.Done():
+			return eventHandler.ctx.Err()
+		}
+		
+		eventHandler.logger.Info(
+			"backfill 进度",
+			zap.String("database", s.Db),
+			zap.String("table", s.Table),
+			zap.Int64("rows", rows),
+			zap.Strings("lastKey", chunker.LastKey()),
+		)
+		
+		if !more {
+			return nil
+		}
+	}
+}
+
+func (eventHandler *EventHandler) createWatermarkTable(executor Executor) error {
+	schema, table := eventHandler.watermarkTable()
+	_, err := executor.Execute(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", quoteName(schema)))
+	if err != nil {
+		return err
+	}
+	
+	_, err = executor.Execute(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s.%s ("+
+			"`name` VARCHAR(191) NOT NULL PRIMARY KEY, "+
+			"`value` VARCHAR(64) NOT NULL, "+
+			"`updated_at` DATETIME NOT NULL"+
+			")", quoteName(schema), quoteName(table)))
+	return err
+}
+
+// writeWatermark 写入 watermark, value 格式为 <窗口 id>:<low|high>
+
+func (eventHandler *EventHandler) writeWatermark(executor Executor, name, id, kind string) error {
+	schema, table := eventHandler.watermarkTable()
+	_, err := executor.Execute(fmt.Sprintf(
+		"INSERT INTO %s.%s (`name`, `value`, `updated_at`) VALUES (?, ?, NOW()) "+
+			"ON DUPLICATE KEY UPDATE `value` = VALUES(`value`), `updated_at` = VALUES(`updated_at`)",
+		quoteName(schema), quoteName(table)), name, id+":"+kind)
+	return err
+}
+
+// onWatermark 处理 binlog 中的 watermark, 在 canal 协程中调用
+// high watermark 时将窗口内没有变更过的行写入 Meilisearch, 并记录进度, 与之后的位置一起写入 checkpoint
+
+func (eventHandler *EventHandler) onWatermark(e *canal.RowsEvent) error {
+	if e.Action == canal.DeleteAction {
+		return nil
+	}
+	
+	column := e.Table.FindColumn("value")
+	if column < 0 {
+		return nil
+	}
+	
+	eventHandler.resyncLock.Lock()
+	defer eventHandler.resyncLock.Unlock()
+	
+	for i, row := range e.Rows {
+		// update 事件中 Rows 按 [before, after] 成对出现, 只处理 after
+		if e.Action == canal.UpdateAction && i%2 == 0 {
+			continue
+		}
+		
+		if column >= len(row) {
+			continue
+		}
+		
+		id, kind, ok := strings.Cut(valueString(row[column]), ":")
+		if !ok {
+			continue
+		}
+		
+		// 其它实例或者已经结束的窗口
+		window, ok := eventHandler.windows[id]
+		if !ok {
+			continue
+		}
+		
+		switch kind {
+		case watermarkLow:
+			window.open = true
+		
+		case watermarkHigh:
+			err := eventHandler.closeWindow(window)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// closeWindow 调用时需要持有 resyncLock
+
+func (eventHandler *EventHandler) closeWindow(window *watermarkWindow) error {
+	s := window.job.s
+	primaryKey := documentPrimaryKey(s)
+	
+	var docs []map[string]interface{}
+	for _, doc := range window.docs {
+		if !window.truncated && !window.seen[identifierString(doc[primaryKey])] {
+			docs = append(docs, doc)
+		}
+	}
+	
+	if len(docs) > 0 {
+		err := eventHandler.meiliSearchClient.CreateDocs(s.Index, docs, primaryKey)
+		if err != nil {
+			return err
+		}
+	}
+	
+	if eventHandler.tableProgress == nil {
+		eventHandler.tableProgress = make(map[string]*TableSnapshot)
+	}
+	eventHandler.tableProgress[window.job.key] = window.progress
+	
+	delete(eventHandler.windows, window.id)
+	close(window.done)
+	return nil
+}
+
+// backfillWindow 返回表当前打开的 watermark 窗口, 调用时需要持有 resyncLock
+
+func (eventHandler *EventHandler) backfillWindow(s *conf.Sync) *watermarkWindow {
+	job, ok := eventHandler.backfills[fmt.Sprintf("%s.%s", s.Db, s.Table)]
+	if !ok {
+		return nil
+	}
+	return job.window
+}
@@ -7,36 +7,86 @@ import (
 	"github.com/go-mysql-org/go-mysql/canal"
 	"github.com/go-mysql-org/go-mysql/mysql"
 	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/go-mysql-org/go-mysql/schema"
 	"github.com/qx66/mysql-meilisearch/internal/conf"
 	"github.com/qx66/mysql-meilisearch/pkg/meilisearch"
 	"go.uber.org/zap"
-	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 )
 
+// PendingCheckpoint 等待写入的同步位置
+// 只有 TaskUIDs 中的 Meilisearch 任务全部执行成功后, 才会写入 checkpoint 文件
+// Tables 为该位置之前 backfill 完成的进度, Renames / ColumnRenames 为该位置之前同步表与列的改名, Schemas 为该位置时的表结构, 与位置一起写入
+
+type PendingCheckpoint struct {
+	Position      mysql.Position
+	GTIDSet       mysql.GTIDSet
+	TaskUIDs      []int64
+	Tables        map[string]*TableSnapshot
+	Renames       []TableRename
+	ColumnRenames []ColumnRename
+	Schemas       map[string]*schema.Table
+}
+
 type EventHandler struct {
 	sync.RWMutex
 	canal.DummyEventHandler
 	meiliSearchClient *meilisearch.Client
-	posCh             chan mysql.Position
+	posCh             chan PendingCheckpoint
+	gtidMode          bool
+	checkpoint        *Checkpoint
+	checkpointStore   CheckpointStore
 	ctx               context.Context
 	cancel            context.CancelFunc
-	dataDir           string
+	// 当前事务是否只写入了 checkpoint 表 (mysql checkpoint 存储), 这类事务不推进 checkpoint, 避免循环写入
+	txnRows           bool
+	txnCheckpointRows bool
+	skipPosSynced     bool
 	logger            *zap.Logger
 	sync              []*conf.Sync
+	snapshotConf      *conf.Snapshot
+	canal             *canal.Canal
+	canalConfig       *canal.Config
+	// OnRow 处理期间持有, resync 最后一步持有以暂停 binlog 处理; backfill 的 watermark 窗口同样由它保护
+	resyncLock sync.Mutex
+	resyncs    map[string]*resyncJob
+	backfills  map[string]*backfillJob
+	windows    map[string]*watermarkWindow
+	// backfill 已写入但尚未随位置提交的进度, 只在 canal 协程中访问
+	tableProgress map[string]*TableSnapshot
+	// 同步配置中原始的表名 (db.table), 表被 RENAME 之后不变; renames 为尚未随位置提交的改名, 只在 canal 协程中访问
+	sources map[*conf.Sync]string
+	renames []TableRename
+	// 尚未随位置提交的列改名, 只在 canal 协程中访问
+	columnRenames []ColumnRename
+	// 按 binlog 顺序维护的同步表表结构, pendingSchemas 为尚未随位置提交的变化, 只在 canal 协程中访问
+	schemas        map[string]*schema.Table
+	pendingSchemas map[string]*schema.Table
+	// binlog 同步期间读取子表 (relations) 使用的连接, 只在 canal 协程中访问
+	relationConn *Snapshot
 }
 
-func NewEventHandler(ctx context.Context, meiliSearchClient *meilisearch.Client, sync []*conf.Sync, dataDir string, logger *zap.Logger) *EventHandler {
-	posCh := make(chan mysql.Position, 4096)
+func NewEventHandler(ctx context.Context, meiliSearchClient *meilisearch.Client, sync []*conf.Sync, checkpointStore CheckpointStore, logger *zap.Logger) *EventHandler {
+	posCh := make(chan PendingCheckpoint, 4096)
+	
+	sources := make(map[*conf.Sync]string, len(sync))
+	for _, s := range sync {
+		sources[s] = fmt.Sprintf("%s.%s", s.Db, s.Table)
+	}
 	
 	return &EventHandler{
 		ctx:               ctx,
 		meiliSearchClient: meiliSearchClient,
 		sync:              sync,
-		dataDir:           dataDir,
+		checkpointStore:   checkpointStore,
 		logger:            logger,
 		posCh:             posCh,
+		resyncs:           make(map[string]*resyncJob),
+		backfills:         make(map[string]*backfillJob),
+		windows:           make(map[string]*watermarkWindow),
+		sources:           sources,
+		schemas:           make(map[string]*schema.Table),
 	}
 }
 
@@ -44,24 +94,113 @@ func (eventHandler *EventHandler) SetCancel(cancel context.CancelFunc) {
 	eventHandler.cancel = cancel
 }
 
-func (eventHandler *EventHandler) SetPosChannel(posCh chan mysql.Position) {
+// SetCanal 设置 canal 及其配置, resync 时用于获取表结构以及打开单独的连接读取表数据
+
+func (eventHandler *EventHandler) SetCanal(c *canal.Canal, config *canal.Config) {
+	eventHandler.canal = c
+	eventHandler.canalConfig = config
+}
+
+// SetSnapshotConf 设置全量同步配置
+
+func (eventHandler *EventHandler) SetSnapshotConf(snapshotConf *conf.Snapshot) {
+	eventHandler.snapshotConf = snapshotConf
+}
+
+func (eventHandler *EventHandler) SetPosChannel(posCh chan PendingCheckpoint) {
 	eventHandler.posCh = posCh
 }
 
+// 开启 GTID 模式后, OnPosSynced 会将已执行的 GTIDSet 写入 checkpoint 文件
+
+func (eventHandler *EventHandler) SetGTIDMode(gtidMode bool) {
+	eventHandler.gtidMode = gtidMode
+}
+
+// SetCheckpoint 设置当前同步位置, SavePos 在此基础上更新并写入 checkpoint 文件
+// 同步配置中的表之前被 RENAME 过时, 按 checkpoint 中记录的当前表名同步;
+// 列被改名过时, 同步配置中引用的列跟随当前列名, 并重新设置 index 属性
+
+func (eventHandler *EventHandler) SetCheckpoint(checkpoint *Checkpoint) error {
+	renamed := eventHandler.setCheckpoint(checkpoint)
+	for _, s := range renamed {
+		err := eventHandler.updateIndexAttributes(s.Index, s)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (eventHandler *EventHandler) setCheckpoint(checkpoint *Checkpoint) []*conf.Sync {
+	eventHandler.Lock()
+	defer eventHandler.Unlock()
+	
+	var renamed []*conf.Sync
+	for _, s := range eventHandler.sync {
+		columns := checkpoint.ColumnRenames[eventHandler.sources[s]]
+		if len(columns) == 0 {
+			continue
+		}
+		
+		eventHandler.logger.Info(
+			"同步表的列已改名, 按新列名同步",
+			zap.String("source", eventHandler.sources[s]),
+			zap.Any("renamed", columns),
+		)
+		renameSyncColumns(s, columns)
+		renamed = append(renamed, s)
+	}
+	
+	eventHandler.checkpoint = checkpoint
+	for key, table := range checkpoint.Schemas {
+		eventHandler.schemas[key] = table
+	}
+	
+	for _, s := range eventHandler.sync {
+		current, ok := checkpoint.Renames[eventHandler.sources[s]]
+		if !ok {
+			continue
+		}
+		
+		db, table, ok := strings.Cut(current, ".")
+		if !ok {
+			continue
+		}
+		
+		eventHandler.logger.Info(
+			"同步表已改名, 按新表名同步",
+			zap.String("source", eventHandler.sources[s]),
+			zap.String("current", current),
+		)
+		s.Db = db
+		s.Table = table
+	}
+	return renamed
+}
+
 // 当binlog日志轮转时
 
 func (eventHandler *EventHandler) OnRotate(header *replication.EventHeader, event *replication.RotateEvent) error {
-	eventHandler.posCh <- mysql.Position{
-		Name: string(event.NextLogName),
-		Pos:  uint32(event.Position),
-	}
+	eventHandler.pushPos(PendingCheckpoint{
+		Position: mysql.Position{
+			Name: string(event.NextLogName),
+			Pos:  uint32(event.Position),
+		},
+	})
 	return nil
 }
 
 // 当执行 DDL 语句时 (⚠️注意: OnTableChanged 在其之前执行)
+// DDL 对应的 Meilisearch 任务先提交, 再推进 checkpoint
 
 func (eventHandler *EventHandler) OnDDL(header *replication.EventHeader, nextPos mysql.Position, q *replication.QueryEvent) error {
-	eventHandler.posCh <- nextPos
+	err := eventHandler.handleDDL(q)
+	if err != nil {
+		return err
+	}
+	
+	eventHandler.pushPos(PendingCheckpoint{Position: nextPos})
 	return nil
 }
 
@@ -70,10 +209,40 @@ func (eventHandler *EventHandler) OnDDL(header *replication.EventHeader, nextPos
 // XID 也在数据库管理工具和监控工具中用于识别和跟踪事务的执行。
 
 func (eventHandler *EventHandler) OnXID(header *replication.EventHeader, nextPos mysql.Position) error {
-	eventHandler.posCh <- nextPos
+	skip := eventHandler.txnCheckpointRows && !eventHandler.txnRows
+	eventHandler.txnRows = false
+	eventHandler.txnCheckpointRows = false
+	if skip {
+		eventHandler.skipPosSynced = true
+		return nil
+	}
+	
+	eventHandler.pushPos(PendingCheckpoint{Position: nextPos})
 	return nil
 }
 
+// isCheckpointTable 是否为 mysql checkpoint 存储使用的表
+
+func (eventHandler *EventHandler) isCheckpointTable(schema, table string) bool {
+	store, ok := eventHandler.checkpointStore.(*MysqlCheckpointStore)
+	return ok && store.IsCheckpointTable(schema, table)
+}
+
+// pushPos 将该位置之前提交的 Meilisearch 任务与位置一起交给 SavePos 协程
+
+func (eventHandler *EventHandler) pushPos(pending PendingCheckpoint) {
+	pending.TaskUIDs = eventHandler.meiliSearchClient.Tracker().Take()
+	pending.Tables = eventHandler.tableProgress
+	eventHandler.tableProgress = nil
+	pending.Renames = eventHandler.renames
+	eventHandler.renames = nil
+	pending.ColumnRenames = eventHandler.columnRenames
+	eventHandler.columnRenames = nil
+	pending.Schemas = eventHandler.pendingSchemas
+	eventHandler.pendingSchemas = nil
+	eventHandler.posCh <- pending
+}
+
 // OnTableChanged is called when the table is created, altered, renamed or dropped.
 // You need to clear the associated data like cache with the table.
 // It will be called before OnDDL.
@@ -93,140 +262,305 @@ func (eventHandler *EventHandler) OnTableChanged(header *replication.EventHeader
 // OnPosSynced Use your own way to sync position. When force is true, sync position immediately.
 // OnPosSynced 使用你自己的方法同步Position, 当force为真时, 立即同步Position
 // GTID（Global Transaction ID）是MySQL中的全局事务标识符，用于唯一标识并跟踪分布式环境中的事务
+// set 为 canal 内部持续更新的对象, 需要 Clone 之后再交给 SavePos 协程
 
 func (eventHandler *EventHandler) OnPosSynced(header *replication.EventHeader, pos mysql.Position, set mysql.GTIDSet, force bool) error {
+	if eventHandler.skipPosSynced {
+		eventHandler.skipPosSynced = false
+		return nil
+	}
+	
+	if !eventHandler.gtidMode || set == nil {
+		return nil
+	}
+	
+	eventHandler.pushPos(PendingCheckpoint{Position: pos, GTIDSet: set.Clone()})
 	return nil
 }
 
-// 当表内容发生变化时, e.Table.Columns 以最新的表内容为准
+// 当表内容发生变化时, e.Table.Columns 以最新的表内容为准, 行数据按 rowColumns 返回的表结构解析
 
 func (eventHandler *EventHandler) OnRow(e *canal.RowsEvent) error {
 	
 	database := e.Table.Schema
 	table := e.Table.Name
 	action := e.Action
-	tableColumns := e.Table.Columns // 表结构列 Table Columns
 	
-	var hasHit bool = false
-	var index string
-	var primaryKey string
+	if eventHandler.isCheckpointTable(database, table) {
+		eventHandler.txnCheckpointRows = true
+		return nil
+	}
+	eventHandler.txnRows = true
+	
+	if eventHandler.isWatermarkTable(database, table) {
+		return eventHandler.onWatermark(e)
+	}
+	
+	// 子表变更更新父表文档, 子表本身也可以是同步表
+	err := eventHandler.onChildRows(e)
+	if err != nil {
+		return err
+	}
 	
+	var hit *conf.Sync
 	for _, s := range eventHandler.sync {
 		
 		if database == s.Db && table == s.Table {
-			index = s.Index
-			hasHit = true
-			primaryKey = s.PrimaryKey
+			hit = s
 		}
 	}
 	
-	if !hasHit {
+	if hit == nil {
 		return nil
 	}
 	
+	// 表结构列 Table Columns
+	tableColumns, err := eventHandler.rowColumns(hit, e)
+	if err != nil {
+		// 无法确定行数据对应的列, 不能跳过该事件 (删除或修改主键的行会在 index 中残留), 停止同步
+		eventHandler.logger.Error(
+			"表结构与行数据不一致, 停止同步",
+			zap.String("database", database),
+			zap.String("table", table),
+			zap.String("action", action),
+			zap.Error(err),
+		)
+		return err
+	}
+	
+	primaryKey := documentPrimaryKey(hit)
+	
+	// resync 期间变更同时写入影子 index, 并记录变更过的行, 复制完成后重新读取
+	eventHandler.resyncLock.Lock()
+	defer eventHandler.resyncLock.Unlock()
+	
+	indexes := []string{hit.Index}
+	job := eventHandler.resyncs[hit.Index]
+	if job != nil && job.active {
+		indexes = append(indexes, job.shadow)
+	}
+	
+	// backfill 窗口内变更过的行, 以 binlog 中的数据为准
+	window := eventHandler.backfillWindow(hit)
+	
 	switch action {
 	// delete from table; 不带 where 语句，会解析成 count(1) 条记录； count(1) = len(rows)
 	case canal.DeleteAction:
 		
-		delData := e.Rows[0]
 		eventHandler.logger.Info(
 			"DeleteAction",
 			zap.String("database", database),
 			zap.String("table", table),
 			zap.String("action", action),
-			zap.Any("delData", delData),
+			zap.Int("rows", len(e.Rows)),
 		)
-		/*
-			for _, row := range e.Rows {
-				for x, r := range row {
-					fmt.Printf("delete => %s %s.%s %s = %v.\n", action, database, tableName, tableColumns[x].Name, r)
-				}
-			}
-		*/
 		
-		var identifier string
-		for x, columns := range tableColumns {
-			if columns.Name == primaryKey {
-				if v, ok := delData[x].(string); ok {
-					identifier = v
-				}
+		var identifiers []string
+		for _, delData := range e.Rows {
+			identifier, err := rowIdentifier(tableColumns, delData, hit)
+			if err != nil {
+				return fmt.Errorf("DeleteAction %w", err)
+			}
+			
+			window.observe(identifier)
+			
+			err = job.markDirty(hit, tableColumns, delData)
+			if err != nil {
+				return fmt.Errorf("DeleteAction %w", err)
+			}
+			
+			// 不满足 filter 或已被软删除的行没有对应的文档
+			matched, err := rowIndexed(hit, tableColumns, delData)
+			if err != nil {
+				return fmt.Errorf("DeleteAction %w", err)
+			}
+			if matched {
+				identifiers = append(identifiers, identifierString(identifier))
 			}
 		}
 		
-		if identifier == "" {
-			return errors.New("DeleteAction 未匹配 identifier 值")
+		if len(identifiers) == 0 {
+			return nil
 		}
 		
-		return eventHandler.meiliSearchClient.DeleteDocument(index, identifier)
-	//
+		for _, index := range indexes {
+			err := eventHandler.indexClient(job, index).DeleteDocuments(index, identifiers)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	// update 事件中 Rows 按 [before, after, before, after, ...] 成对出现
 	case canal.UpdateAction:
-		srcData := e.Rows[0]
-		newData := e.Rows[1]
 		
 		eventHandler.logger.Info(
 			"UpdateAction",
 			zap.String("database", database),
 			zap.String("table", table),
 			zap.String("action", action),
-			
-			zap.Any("srcData", srcData),
-			zap.Any("newData", newData),
+			zap.Int("rows", len(e.Rows)/2),
 		)
-		/*
-			for _, row := range e.Rows {
-				for x, r := range row {
-					fmt.Printf("update => %s %s.%s %s = %v.\n", action, database, tableName, tableColumns[x].Name, r)
+		
+		if len(e.Rows)%2 != 0 {
+			return errors.New("UpdateAction 行数据不成对")
+		}
+		
+		var docs []map[string]interface{}
+		var docRows [][]interface{}
+		var staleIdentifiers []string
+		for i := 0; i < len(e.Rows); i += 2 {
+			srcData := e.Rows[i]
+			newData := e.Rows[i+1]
+			
+			// 长度不一致，可能因为表结构已经发生变化
+			if len(srcData) != len(tableColumns) || len(newData) != len(tableColumns) {
+				return errors.New("表结构可能发生变化")
+			}
+			
+			doc, err := eventHandler.buildDoc(tableColumns, newData, hit)
+			if err != nil {
+				return fmt.Errorf("UpdateAction %w", err)
+			}
+			
+			// 主键发生变化时, 旧 id 对应的文档需要删除
+			srcIdentifier, err := rowIdentifier(tableColumns, srcData, hit)
+			if err != nil {
+				return fmt.Errorf("UpdateAction %w", err)
+			}
+			
+			window.observe(srcIdentifier)
+			window.observe(doc[primaryKey])
+			
+			// 更新后进入 filter 时写入文档, 离开 filter 或被软删除时删除文档 (tombstone 模式下写入标记)
+			srcMatched, err := rowIndexed(hit, tableColumns, srcData)
+			if err != nil {
+				return fmt.Errorf("UpdateAction %w", err)
+			}
+			newMatched, err := rowIndexed(hit, tableColumns, newData)
+			if err != nil {
+				return fmt.Errorf("UpdateAction %w", err)
+			}
+			
+			if !newMatched {
+				if srcMatched {
+					staleIdentifiers = append(staleIdentifiers, identifierString(srcIdentifier))
+				}
+				
+				err = job.markDirty(hit, tableColumns, srcData)
+				if err != nil {
+					return fmt.Errorf("UpdateAction %w", err)
+				}
+				continue
+			}
+			
+			if identifierString(srcIdentifier) != identifierString(doc[primaryKey]) {
+				staleIdentifiers = append(staleIdentifiers, identifierString(srcIdentifier))
+				
+				err = job.markDirty(hit, tableColumns, srcData)
+				if err != nil {
+					return fmt.Errorf("UpdateAction %w", err)
+				}
+			}
+			
+			err = job.markDirty(hit, tableColumns, newData)
+			if err != nil {
+				return fmt.Errorf("UpdateAction %w", err)
+			}
+			
+			docs = append(docs, doc)
+			docRows = append(docRows, newData)
+		}
+		
+		err = eventHandler.embedStreamRelations(hit, tableColumns, docRows, docs)
+		if err != nil {
+			return fmt.Errorf("UpdateAction %w", err)
+		}
+		
+		if len(staleIdentifiers) > 0 {
+			eventHandler.logger.Info(
+				"UpdateAction 主键发生变化、不再满足 filter 或被软删除, 删除旧文档",
+				zap.String("database", database),
+				zap.String("table", table),
+				zap.Strings("identifiers", staleIdentifiers),
+			)
+			
+			for _, index := range indexes {
+				err := eventHandler.indexClient(job, index).DeleteDocuments(index, staleIdentifiers)
+				if err != nil {
+					return err
 				}
 			}
-		*/
+		}
 		
-		docs := make(map[string]interface{})
-		for x, columns := range tableColumns {
-			name := columns.Name
-			docs[name] = newData[x]
+		if len(docs) == 0 {
+			return nil
 		}
 		
-		return eventHandler.meiliSearchClient.UpdateDocuments(index, primaryKey, docs)
+		for _, index := range indexes {
+			err := eventHandler.indexClient(job, index).UpdateDocuments(index, primaryKey, docs)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	
 	case canal.InsertAction:
 		
-		newData := e.Rows[0]
-		//mapData := make(map[string]interface{})
-		
 		eventHandler.logger.Info(
 			"InsertAction",
 			zap.String("database", database),
 			zap.String("table", table),
 			zap.String("action", action),
-			
-			zap.Any("tableColumns", tableColumns),
-			zap.Any("newData", newData),
+			zap.Int("rows", len(e.Rows)),
 		)
 		
-		// 长度不一致，可能因为表结构已经发生变化
-		if len(newData) != len(tableColumns) {
-			return errors.New("表结构可能发生变化")
-		}
-		
-		docs := make(map[string]interface{})
-		for x, columns := range tableColumns {
-			name := columns.Name
-			columnData := newData[x]
+		var docs []map[string]interface{}
+		var docRows [][]interface{}
+		for _, newData := range e.Rows {
+			
+			// 长度不一致，可能因为表结构已经发生变化
+			if len(newData) != len(tableColumns) {
+				return errors.New("表结构可能发生变化")
+			}
+			
+			doc, err := eventHandler.buildDoc(tableColumns, newData, hit)
+			if err != nil {
+				return fmt.Errorf("InsertAction %w", err)
+			}
 			
-			switch v := columnData.(type) {
-			case string:
-				docs[name] = v
-			case int:
-				docs[name] = v
-			case []byte:
-				docs[name] = string(v)
-			default:
-				docs[name] = v
+			err = job.markDirty(hit, tableColumns, newData)
+			if err != nil {
+				return fmt.Errorf("InsertAction %w", err)
+			}
+			window.observe(doc[primaryKey])
+			
+			// 与全量读取一致, 跳过已被软删除的行
+			matched, err := rowLive(hit, tableColumns, newData)
+			if err != nil {
+				return fmt.Errorf("InsertAction %w", err)
+			}
+			if matched {
+				docs = append(docs, doc)
+				docRows = append(docRows, newData)
 			}
 		}
 		
-		err := eventHandler.meiliSearchClient.CreateDocs(index, docs, primaryKey)
-		return err
+		err = eventHandler.embedStreamRelations(hit, tableColumns, docRows, docs)
+		if err != nil {
+			return fmt.Errorf("InsertAction %w", err)
+		}
+		
+		if len(docs) == 0 {
+			return nil
+		}
+		
+		for _, index := range indexes {
+			err := eventHandler.indexClient(job, index).CreateDocs(index, docs, primaryKey)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	
 	default:
 		eventHandler.logger.Error(
@@ -240,32 +574,107 @@ func (eventHandler *EventHandler) OnRow(e *canal.RowsEvent) error {
 	return nil
 }
 
+// rowToDoc 将一行数据按表结构列转换为 Meilisearch 文档, 主键字段使用规范化之后的文档 id
+
+func rowToDoc(tableColumns []schema.TableColumn, row []interface{}, s *conf.Sync) (map[string]interface{}, error) {
+	identifier, err := rowIdentifier(tableColumns, row, s)
+	if err != nil {
+		return nil, err
+	}
+	
+	doc := make(map[string]interface{})
+	for x, column := range tableColumns {
+		if isVectorColumn(s, column.Name) || !includeColumn(s, column.Name) {
+			continue
+		}
+		
+		value, ok := convertColumn(column, row[x], s.Converters[column.Name])
+		if ok {
+			doc[documentField(s, column.Name)] = value
+		}
+	}
+	projectJSON(s, tableColumns, row, doc)
+	buildGeo(s, tableColumns, row, doc)
+	buildVectors(s, tableColumns, row, doc)
+	
+	err = markTombstone(s, tableColumns, row, doc)
+	if err != nil {
+		return nil, err
+	}
+	
+	doc[documentPrimaryKey(s)] = identifier
+	return doc, nil
+}
+
+// buildDoc 在 rowToDoc 的基础上, 多张表写入同一个 index 时在文档中记录来源表
+
+func (eventHandler *EventHandler) buildDoc(tableColumns []schema.TableColumn, row []interface{}, s *conf.Sync) (map[string]interface{}, error) {
+	doc, err := rowToDoc(tableColumns, row, s)
+	if err != nil {
+		return nil, err
+	}
+	
+	if field := eventHandler.sourceField(s); field != "" {
+		doc[field] = eventHandler.sourceTable(s)
+	}
+	return doc, nil
+}
+
 // 每次执行前进行更新
 
 func (eventHandler *EventHandler) UpdateAttributes() error {
 	for _, s := range eventHandler.sync {
-		primaryKey := s.PrimaryKey
-		filterAbleField := s.FilterAbleField
+		err := checkPrimaryKey(s)
+		if err != nil {
+			return err
+		}
 		
-		var filterAbleFieldHasPrimaryKey bool = false
-		for _, f := range filterAbleField {
-			if f == primaryKey {
-				filterAbleFieldHasPrimaryKey = true
-			}
+		err = checkTablePolicies(s)
+		if err != nil {
+			return err
+		}
+		
+		err = checkConverters(s)
+		if err != nil {
+			return err
+		}
+		
+		err = checkJSONPaths(s)
+		if err != nil {
+			return err
+		}
+		
+		err = checkGeo(s)
+		if err != nil {
+			return err
+		}
+		
+		err = checkVectors(s)
+		if err != nil {
+			return err
+		}
+		
+		err = checkColumns(s)
+		if err != nil {
+			return err
 		}
 		
-		if !filterAbleFieldHasPrimaryKey {
-			filterAbleField = append(filterAbleField, primaryKey)
+		err = checkFilter(s)
+		if err != nil {
+			return err
 		}
 		
-		//
-		err := eventHandler.meiliSearchClient.CreateIndex(s.Index, primaryKey)
+		err = checkSoftDelete(s)
 		if err != nil {
 			return err
 		}
 		
-		//
-		err = eventHandler.meiliSearchClient.UpdateAttributes(s.Index, filterAbleField...)
+		err = checkRelations(s)
+		if err != nil {
+			return err
+		}
+		
+		err = eventHandler.updateIndexAttributes(s.Index, s)
 		if err != nil {
 			return err
 		}
@@ -273,136 +682,251 @@ func (eventHandler *EventHandler) UpdateAttributes() error {
 	return nil
 }
 
+// updateIndexAttributes 按同步配置创建 index 并设置属性, resync 时影子 index 使用相同的设置
+
+func (eventHandler *EventHandler) updateIndexAttributes(index string, s *conf.Sync) error {
+	primaryKey := documentPrimaryKey(s)
+	filterAbleField := append([]string{}, s.FilterAbleField...)
+	
+	// 共用 index 时 filterable 设置会相互覆盖, 合并所有表的配置, 并加入来源表字段
+	if field := eventHandler.sourceField(s); field != "" {
+		for _, other := range eventHandler.sync {
+			if other != s && other.Index == s.Index {
+				filterAbleField = append(filterAbleField, other.FilterAbleField...)
+			}
+		}
+		filterAbleField = append(filterAbleField, field)
+	}
+	filterAbleField = append(filterAbleField, primaryKey)
+	
+	// 任意一张表生成 _geo 时, _geo 需要设置为 filterable 与 sortable
+	geo := false
+	for _, other := range eventHandler.sync {
+		if other.Index == s.Index && hasGeo(other) {
+			geo = true
+		}
+	}
+	if geo {
+		filterAbleField = append(filterAbleField, geoField)
+	}
+	
+	// tombstone 标记字段需要设置为 filterable, 搜索时过滤已删除的文档
+	for _, other := range eventHandler.sync {
+		if other.Index == s.Index && isTombstone(other) {
+			filterAbleField = append(filterAbleField, tombstoneField(other))
+		}
+	}
+	
+	filterAbleField = uniqueStrings(filterAbleField)
+	
+	//
+	err := eventHandler.meiliSearchClient.CreateIndex(index, primaryKey)
+	if err != nil {
+		return err
+	}
+	
+	//
+	err = eventHandler.meiliSearchClient.UpdateAttributes(index, filterAbleField...)
+	if err != nil {
+		return err
+	}
+	
+	// sortable 设置会整体覆盖, 合并 index 当前的设置, 避免清除其他地方配置的 sortable 字段
+	// resync 时影子 index 还没有设置, 同时合并正在使用的 index 的设置
+	if geo {
+		sortableField := []string{geoField}
+		for _, name := range uniqueStrings([]string{index, s.Index}) {
+			current, err := eventHandler.meiliSearchClient.GetSortableAttributes(name)
+			if err != nil {
+				return err
+			}
+			sortableField = append(sortableField, current...)
+		}
+		
+		err = eventHandler.meiliSearchClient.UpdateSortableAttributes(index, uniqueStrings(sortableField)...)
+		if err != nil {
+			return err
+		}
+	}
+	
+	// 向量由同步的数据提供, embedder 设置为 userProvided
+	embedders, err := eventHandler.indexEmbedders(s)
+	if err != nil {
+		return err
+	}
+	if len(embedders) > 0 {
+		return eventHandler.meiliSearchClient.UpdateEmbedders(index, embedders)
+	}
+	return nil
+}
+
+// SavePos 按顺序等待每个位置之前的 Meilisearch 任务执行成功后再写入 checkpoint (at-least-once)
+// 任务执行失败时停止推进 checkpoint 并取消 ctx, 重启后从上一个成功的位置重新同步
+
 func (eventHandler *EventHandler) SavePos() {
 	for {
 		select {
-		case position := <-eventHandler.posCh:
-			eventHandler.savePos(position)
+		case pending := <-eventHandler.posCh:
+			// 合并已经在队列中的位置, 只写入一次 checkpoint 文件
+			pendings := []PendingCheckpoint{pending}
+		drain:
+			for len(pendings) < cap(eventHandler.posCh) {
+				select {
+				case next := <-eventHandler.posCh:
+					pendings = append(pendings, next)
+				default:
+					break drain
+				}
+			}
+			
+			var applied bool
+			for _, p := range pendings {
+				err := eventHandler.meiliSearchClient.Tracker().Wait(eventHandler.ctx, p.TaskUIDs)
+				if err != nil {
+					if applied {
+						eventHandler.saveCheckpoint()
+					}
+					
+					if eventHandler.ctx.Err() != nil {
+						return
+					}
+					
+					eventHandler.logger.Error(
+						"Meilisearch 任务执行失败, 停止推进 checkpoint",
+						zap.String("position", p.Position.String()),
+						zap.Error(err),
+					)
+					eventHandler.cancel()
+					return
+				}
+				
+				eventHandler.applyPos(p)
+				applied = true
+			}
+			
+			eventHandler.saveCheckpoint()
 		case <-eventHandler.ctx.Done():
 			return
 		}
 	}
 }
 
-func (eventHandler *EventHandler) savePos(position mysql.Position) {
+func (eventHandler *EventHandler) applyPos(pending PendingCheckpoint) {
 	eventHandler.Lock()
 	defer eventHandler.Unlock()
 	
-	binlogPosCheckpointFile := filepath.Join(eventHandler.dataDir, "checkpoint")
-	f, err := os.OpenFile(binlogPosCheckpointFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
-	defer f.Close()
-	
-	if err != nil {
-		eventHandler.logger.Error(
-			"打开文件checkpoint文件失败",
-			zap.String("checkpointFilePath", binlogPosCheckpointFile),
-			zap.Error(err),
-		)
-		return
+	if pending.Position.Name != "" {
+		eventHandler.checkpoint.SetPosition(pending.Position)
+	}
+	if pending.GTIDSet != nil {
+		eventHandler.checkpoint.GTIDSet = pending.GTIDSet.String()
 	}
+	for key, snapshot := range pending.Tables {
+		if eventHandler.checkpoint.Tables == nil {
+			eventHandler.checkpoint.Tables = make(map[string]*TableSnapshot)
+		}
+		eventHandler.checkpoint.Tables[key] = snapshot
+	}
+	for _, rename := range pending.Renames {
+		eventHandler.checkpoint.applyRename(rename)
+	}
+	for _, rename := range pending.ColumnRenames {
+		eventHandler.checkpoint.applyColumnRename(rename)
+	}
+	for key, table := range pending.Schemas {
+		if eventHandler.checkpoint.Schemas == nil {
+			eventHandler.checkpoint.Schemas = make(map[string]*schema.Table)
+		}
+		eventHandler.checkpoint.Schemas[key] = table
+	}
+}
+
+func (eventHandler *EventHandler) saveCheckpoint() {
+	eventHandler.Lock()
+	defer eventHandler.Unlock()
 	
-	s := fmt.Sprintf("%s %d", position.Name, position.Pos)
-	_, err = f.Write([]byte(s))
+	err := eventHandler.checkpointStore.Save(eventHandler.checkpoint)
 	if err != nil {
 		eventHandler.logger.Error(
-			"写入checkpoint文件失败",
-			zap.String("checkpointFilePath", binlogPosCheckpointFile),
+			"写入checkpoint失败",
+			zap.String("checkpointStore", eventHandler.checkpointStore.String()),
 			zap.Error(err),
 		)
 		return
 	}
 	
 	eventHandler.logger.Debug(
-		"写入checkpoint文件成功",
-		zap.String("checkpointFilePath", binlogPosCheckpointFile),
+		"写入checkpoint成功",
+		zap.String("checkpointStore", eventHandler.checkpointStore.String()),
+		zap.String("position", eventHandler.checkpoint.Position().String()),
+		zap.String("gtidSet", eventHandler.checkpoint.GTIDSet),
 	)
-	return
+}
+
+// FlushCheckpoint 等待已提交的 Meilisearch 任务全部成功后, 立即写入 checkpoint (全量同步期间及完成后调用)
+// 先复制 checkpoint 再取出任务, 复制时记录的进度对应的任务都已经提交, 保证写入的进度都已经执行成功
+
+func (eventHandler *EventHandler) FlushCheckpoint() error {
+	eventHandler.RLock()
+	checkpoint := eventHandler.checkpoint.Clone()
+	eventHandler.RUnlock()
+	
+	err := eventHandler.meiliSearchClient.Tracker().Wait(eventHandler.ctx, eventHandler.meiliSearchClient.Tracker().Take())
+	if err != nil {
+		return err
+	}
+	
+	// 与 saveCheckpoint 互斥
+	eventHandler.Lock()
+	defer eventHandler.Unlock()
+	
+	return eventHandler.checkpointStore.Save(checkpoint)
 }
 
 // 第一次执行，初始化全量数据到 MeiliSearch
+// 表结构通过 canal 获取, 表数据由多个 worker 并行读取, 每个 worker 独占 executors 中的一个连接
+// 全量同步开始前先写入起始位置, 中途退出后重新调用时跳过已完成的表, 未完成的表 (区间) 从最后写入成功的一批继续
 
-func (eventHandler *EventHandler) FirstInitTable(c *canal.Canal) error {
+func (eventHandler *EventHandler) FirstInitTable(c *canal.Canal, executors []Executor) error {
+	eventHandler.Lock()
+	eventHandler.checkpoint.SnapshotPending = true
+	eventHandler.Unlock()
 	
-	for _, s := range eventHandler.sync {
-		// 获取 table 信息
-		table, err := c.GetTable(s.Db, s.Table)
-		
-		index := s.Index
-		primaryKey := s.PrimaryKey
-		
+	tasks, err := eventHandler.planCopyTasks(c, executors[0], len(executors), eventHandler.sync, func(s *conf.Sync) (string, *TableSnapshot) {
+		return s.Index, eventHandler.checkpoint.Table(s.Db, s.Table)
+	})
+	if err != nil {
+		return err
+	}
+	
+	err = eventHandler.FlushCheckpoint()
+	if err != nil {
+		eventHandler.logger.Error(
+			"初始化数据库表失败, 写入全量同步起始位置失败",
+			zap.Error(err),
+		)
+		return err
+	}
+	
+	// 定期等待已提交的任务完成并写入 checkpoint, 记录全量同步进度
+	err = eventHandler.runCopyTasks(eventHandler.ctx, executors, tasks, func() error {
+		err := eventHandler.FlushCheckpoint()
 		if err != nil {
 			eventHandler.logger.Error(
-				"初始化数据库表失败, 获取表信息失败",
-				zap.String("database", s.Db),
-				zap.String("table", s.Table),
+				"初始化数据库表失败, 写入全量同步进度失败",
 				zap.Error(err),
 			)
-			return err
 		}
-		
-		// 获取 table 数据
-		var offset int64 = 0
-		for {
-			sql := fmt.Sprintf("select * from %s.%s limit %d, 1000;", s.Db, s.Table, offset)
-			r, err := c.Execute(sql)
-			if err != nil {
-				eventHandler.logger.Error(
-					"初始化数据库表失败,执行SQL失败",
-					zap.String("database", s.Db),
-					zap.String("table", s.Table),
-					zap.String("sql", sql),
-					zap.Error(err),
-				)
-				return err
-			}
-			
-			if len(r.Values) == 0 {
-				break
-			}
-			
-			var docs []map[string]interface{}
-			
-			for _, v := range r.Values {
-				doc := make(map[string]interface{})
-				
-				for n, x := range v {
-					switch rv := x.Value().(type) {
-					case string:
-						doc[table.Columns[n].Name] = rv
-					case int:
-						doc[table.Columns[n].Name] = rv
-					case []byte:
-						doc[table.Columns[n].Name] = string(rv)
-					default:
-						doc[table.Columns[n].Name] = rv
-					}
-				}
-				
-				docs = append(docs, doc)
-			}
-			
-			err = eventHandler.meiliSearchClient.CreateDocs(index, docs, primaryKey)
-			if err != nil {
-				eventHandler.logger.Error(
-					"初始化数据库表失败, 插入数据到 MeiliSearch失败",
-					zap.String("database", s.Db),
-					zap.String("table", s.Table),
-					zap.String("sql", sql),
-					zap.Error(err),
-				)
-				return err
-			}
-			
-			offset += 1000
-		}
-		
-		eventHandler.logger.Info(
-			"初始化数据库表成功",
-			zap.String("database", s.Db),
-			zap.String("table", s.Table),
-		)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 	
+	eventHandler.Lock()
+	eventHandler.checkpoint.SnapshotPending = false
+	eventHandler.Unlock()
+	
 	return nil
 }
 
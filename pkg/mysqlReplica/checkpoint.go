@@ -0,0 +1,397 @@
+package mysqlReplica
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/canal"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CheckpointFile checkpoint 文件名, 位于 binlogCheckpointDir 目录下
+	CheckpointFile = "checkpoint"
+	// checkpoint 文件格式版本, 格式不兼容时需要增加版本号
+	checkpointVersion = 1
+	// 保留的历史 checkpoint 数量 (checkpoint.1 ~ checkpoint.N), 用于回滚
+	checkpointBackups = 3
+	// 历史 checkpoint 的轮转间隔, 每次保存都轮转时历史 checkpoint 只相差几秒, 无法用于回滚
+	checkpointBackupInterval = 10 * time.Minute
+)
+
+// Checkpoint 同步位置, 以 JSON 格式保存
+// SnapshotPending 表示全量同步未完成, 重启后先继续全量同步, 再从 BinlogName/BinlogPos (GTIDSet) 开始监听 binlog
+// Renames 记录同步配置中的表 (db.table) 被 RENAME 之后的当前表名, 重启时按此更新同步配置
+// ColumnRenames 记录同步配置中的表的列被改名之后的当前列名 (原列名 -> 当前列名), 重启时按此更新同步配置中引用的列
+// Schemas 为该位置时同步表的表结构, 从该位置重放 binlog 时用于解析 ALTER 之前的行
+
+type Checkpoint struct {
+	Version         int                          `json:"version"`
+	BinlogName      string                       `json:"binlogName"`
+	BinlogPos       uint32                       `json:"binlogPos"`
+	GTIDSet         string                       `json:"gtidSet,omitempty"`
+	Flavor          string                       `json:"flavor,omitempty"`
+	ServerUUID      string                       `json:"serverUUID,omitempty"`
+	SnapshotPending bool                         `json:"snapshotPending,omitempty"`
+	Tables          map[string]*TableSnapshot    `json:"tables,omitempty"`
+	Renames         map[string]string            `json:"renames,omitempty"`
+	ColumnRenames   map[string]map[string]string `json:"columnRenames,omitempty"`
+	Schemas         map[string]*schema.Table     `json:"schemas,omitempty"`
+	UpdatedAt       time.Time                    `json:"updatedAt"`
+}
+
+// TableSnapshot 表的全量同步状态, key 为 db.table
+// LastKey 为已写入 Meilisearch 的最后一行分页列 (SQL 字面量), 全量同步中途退出后从这里继续
+// 大表拆分为多个主键区间并行读取时, 进度记录在 Ranges 中
+// Backfill 表示正在进行与 binlog 同步交替执行的增量全量同步 (watermark), 重启后从 LastKey 继续
+
+type TableSnapshot struct {
+	Done      bool        `json:"done"`
+	Backfill  bool        `json:"backfill,omitempty"`
+	Rows      int64       `json:"rows"`
+	LastKey   []string    `json:"lastKey,omitempty"`
+	Ranges    []*KeyRange `json:"ranges,omitempty"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+}
+
+// KeyRange 整数主键区间 [Min, Max] 的全量同步状态
+
+type KeyRange struct {
+	Min     int64    `json:"min"`
+	Max     int64    `json:"max"`
+	Done    bool     `json:"done"`
+	Rows    int64    `json:"rows"`
+	LastKey []string `json:"lastKey,omitempty"`
+}
+
+// TableRename 同步表改名, Source 为同步配置中的表名, From/To 为改名前后的表名 (db.table)
+
+type TableRename struct {
+	Source string
+	From   string
+	To     string
+}
+
+// ColumnRename 同步表的列改名, Source 为同步配置中的表名 (db.table), From/To 为改名前后的列名
+
+type ColumnRename struct {
+	Source string
+	From   string
+	To     string
+}
+
+func NewCheckpoint(flavor, serverUUID string) *Checkpoint {
+	return &Checkpoint{
+		Version:    checkpointVersion,
+		Flavor:     flavor,
+		ServerUUID: serverUUID,
+		Tables:     make(map[string]*TableSnapshot),
+	}
+}
+
+func (checkpoint *Checkpoint) Position() mysql.Position {
+	return mysql.Position{
+		Name: checkpoint.BinlogName,
+		Pos:  checkpoint.BinlogPos,
+	}
+}
+
+func (checkpoint *Checkpoint) SetPosition(position mysql.Position) {
+	checkpoint.BinlogName = position.Name
+	checkpoint.BinlogPos = position.Pos
+}
+
+// Table 返回表的全量同步状态, 不存在时创建
+
+func (checkpoint *Checkpoint) Table(db, table string) *TableSnapshot {
+	if checkpoint.Tables == nil {
+		checkpoint.Tables = make(map[string]*TableSnapshot)
+	}
+	
+	key := fmt.Sprintf("%s.%s", db, table)
+	snapshot, ok := checkpoint.Tables[key]
+	if !ok {
+		snapshot = &TableSnapshot{}
+		checkpoint.Tables[key] = snapshot
+	}
+	return snapshot
+}
+
+// applyRename 记录同步表改名后的表名, 并将表的全量同步状态移动到新表名下
+
+func (checkpoint *Checkpoint) applyRename(rename TableRename) {
+	if checkpoint.Renames == nil {
+		checkpoint.Renames = make(map[string]string)
+	}
+	
+	if rename.To == rename.Source {
+		delete(checkpoint.Renames, rename.Source)
+	} else {
+		checkpoint.Renames[rename.Source] = rename.To
+	}
+	
+	if snapshot, ok := checkpoint.Tables[rename.From]; ok {
+		delete(checkpoint.Tables, rename.From)
+		checkpoint.Tables[rename.To] = snapshot
+	}
+	
+	if table, ok := checkpoint.Schemas[rename.From]; ok {
+		delete(checkpoint.Schemas, rename.From)
+		checkpoint.Schemas[rename.To] = table
+	}
+}
+
+// applyColumnRename 记录列改名之后的列名, 多次改名时记录原列名到最新列名的映射
+
+func (checkpoint *Checkpoint) applyColumnRename(rename ColumnRename) {
+	if checkpoint.ColumnRenames == nil {
+		checkpoint.ColumnRenames = make(map[string]map[string]string)
+	}
+	renames := checkpoint.ColumnRenames[rename.Source]
+	if renames == nil {
+		renames = make(map[string]string)
+		checkpoint.ColumnRenames[rename.Source] = renames
+	}
+	
+	original := rename.From
+	for from, current := range renames {
+		if current == rename.From {
+			original = from
+			break
+		}
+	}
+	
+	if original == rename.To {
+		delete(renames, original)
+	} else {
+		renames[original] = rename.To
+	}
+	
+	if len(renames) == 0 {
+		delete(checkpoint.ColumnRenames, rename.Source)
+	}
+}
+
+// Clone 深拷贝 checkpoint, 用于在不持有锁的情况下写入
+
+func (checkpoint *Checkpoint) Clone() *Checkpoint {
+	clone := *checkpoint
+	clone.Tables = make(map[string]*TableSnapshot, len(checkpoint.Tables))
+	for key, table := range checkpoint.Tables {
+		tableClone := *table
+		tableClone.Ranges = make([]*KeyRange, 0, len(table.Ranges))
+		for _, keyRange := range table.Ranges {
+			rangeClone := *keyRange
+			tableClone.Ranges = append(tableClone.Ranges, &rangeClone)
+		}
+		clone.Tables[key] = &tableClone
+	}
+	
+	clone.Renames = make(map[string]string, len(checkpoint.Renames))
+	for source, current := range checkpoint.Renames {
+		clone.Renames[source] = current
+	}
+	
+	clone.ColumnRenames = make(map[string]map[string]string, len(checkpoint.ColumnRenames))
+	for source, renames := range checkpoint.ColumnRenames {
+		renamesClone := make(map[string]string, len(renames))
+		for from, to := range renames {
+			renamesClone[from] = to
+		}
+		clone.ColumnRenames[source] = renamesClone
+	}
+	
+	clone.Schemas = make(map[string]*schema.Table, len(checkpoint.Schemas))
+	for key, table := range checkpoint.Schemas {
+		clone.Schemas[key] = table
+	}
+	return &clone
+}
+
+// LoadCheckpoint 读取 checkpoint 文件, 文件不存在时返回 nil
+// checkpoint 文件损坏时依次尝试历史 checkpoint.1 ~ checkpoint.N
+
+func LoadCheckpoint(dir string, logger *zap.Logger) (*Checkpoint, error) {
+	var lastErr error
+	for i := 0; i <= checkpointBackups; i++ {
+		path := checkpointPath(dir, i)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		
+		checkpoint, err := parseCheckpoint(data)
+		if err != nil {
+			logger.Error(
+				"checkpoint 文件格式不符合规范, 尝试读取上一个 checkpoint",
+				zap.String("checkpointFilePath", path),
+				zap.Error(err),
+			)
+			lastErr = err
+			continue
+		}
+		
+		logger.Info(
+			"从 checkpoint 文件中读取同步位置",
+			zap.String("checkpointFilePath", path),
+			zap.String("Position", checkpoint.Position().String()),
+			zap.String("GTIDSet", checkpoint.GTIDSet),
+			zap.Time("updatedAt", checkpoint.UpdatedAt),
+		)
+		return checkpoint, nil
+	}
+	
+	if lastErr != nil {
+		return nil, fmt.Errorf("没有可用的 checkpoint 文件: %w", lastErr)
+	}
+	return nil, nil
+}
+
+func parseCheckpoint(data []byte) (*Checkpoint, error) {
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return nil, errors.New("checkpoint 文件为空")
+	}
+	
+	// 旧版本格式: "binlog文件名 pos"
+	if !strings.HasPrefix(content, "{") {
+		checkpoints := strings.Split(content, " ")
+		if len(checkpoints) != 2 {
+			return nil, errors.New("checkpoint 文件格式不符合规范")
+		}
+		
+		posNumber, err := strconv.ParseUint(checkpoints[1], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		
+		checkpoint := NewCheckpoint("", "")
+		checkpoint.SetPosition(mysql.Position{Name: checkpoints[0], Pos: uint32(posNumber)})
+		return checkpoint, nil
+	}
+	
+	var checkpoint Checkpoint
+	err := json.Unmarshal(data, &checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	
+	if checkpoint.Version != checkpointVersion {
+		return nil, fmt.Errorf("不支持的 checkpoint 版本: %d", checkpoint.Version)
+	}
+	
+	if checkpoint.BinlogName == "" && checkpoint.GTIDSet == "" {
+		return nil, errors.New("checkpoint 缺少同步位置")
+	}
+	return &checkpoint, nil
+}
+
+// SaveCheckpoint 先写入临时文件并 fsync, 再通过 rename 原子替换 checkpoint 文件
+// checkpoint.1 早于 checkpointBackupInterval 时, 替换前将旧的 checkpoint 轮转为 checkpoint.1 ~ checkpoint.N
+
+func SaveCheckpoint(dir string, checkpoint *Checkpoint) error {
+	checkpoint.Version = checkpointVersion
+	checkpoint.UpdatedAt = time.Now()
+	
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return err
+	}
+	
+	tmpPath := filepath.Join(dir, CheckpointFile+".tmp")
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	
+	if rotateDue(dir) {
+		err = rotateCheckpoints(dir)
+		if err != nil {
+			return err
+		}
+	}
+	
+	err = os.Rename(tmpPath, checkpointPath(dir, 0))
+	if err != nil {
+		return err
+	}
+	
+	return syncDir(dir)
+}
+
+// rotateDue 按 checkpoint.1 的修改时间判断是否需要轮转, 不需要在内存中记录上次轮转的时间
+
+func rotateDue(dir string) bool {
+	info, err := os.Stat(checkpointPath(dir, 1))
+	if err != nil {
+		return true
+	}
+	return time.Since(info.ModTime()) >= checkpointBackupInterval
+}
+
+// rotateCheckpoints 轮转历史 checkpoint, 中途崩溃时 LoadCheckpoint 会读取 checkpoint.1
+
+func rotateCheckpoints(dir string) error {
+	for i := checkpointBackups; i > 0; i-- {
+		err := os.Rename(checkpointPath(dir, i-1), checkpointPath(dir, i))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkpointPath(dir string, backup int) string {
+	if backup == 0 {
+		return filepath.Join(dir, CheckpointFile)
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.%d", CheckpointFile, backup))
+}
+
+// rename 之后需要 fsync 目录, 保证目录项落盘
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// ServerUUID 获取 MySQL 实例标识, 用于判断 checkpoint 是否属于当前实例
+// MariaDB 没有 server_uuid, 使用 server_id 代替
+
+func ServerUUID(c *canal.Canal, flavor string) (string, error) {
+	query := "SELECT @@server_uuid"
+	if flavor == mysql.MariaDBFlavor {
+		query = "SELECT @@server_id"
+	}
+	
+	r, err := c.Execute(query)
+	if err != nil {
+		return "", err
+	}
+	return r.GetString(0, 0)
+}
@@ -0,0 +1,282 @@
+package mysqlReplica
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 全量同步默认每批读取的行数
+	defaultSnapshotChunkSize = 1000
+	// 全量同步期间写入 checkpoint 的间隔
+	snapshotCheckpointInterval = 10 * time.Second
+)
+
+// tableChunker 按分页列顺序分批读取表数据: WHERE (pk) > (last) ORDER BY pk LIMIT n
+// 与 LIMIT offset 相比每一批只需要扫描 n 行, 并且读取期间表数据变化时不会跳过或重复读取
+
+type tableChunker struct {
+	db        string
+	table     string
+	columns   []schema.TableColumn
+	keyNames  []string
+	keyIndex  []int
+	chunkSize int
+	// 上一批最后一行分页列的 SQL 字面量, 为空时从 lowerKey 开始读取
+	lastKey []string
+	// 读取范围 [lowerKey, upperKey], 为空时不限制
+	lowerKey []string
+	upperKey []string
+	// 同步配置中的行过滤条件与软删除条件, 为空时读取所有行
+	filter string
+}
+
+func newTableChunker(table *schema.Table, s *conf.Sync, chunkSize int) (*tableChunker, error) {
+	if chunkSize <= 0 {
+		chunkSize = defaultSnapshotChunkSize
+	}
+	
+	filter, err := snapshotFilterSQL(s)
+	if err != nil {
+		return nil, err
+	}
+	
+	columns := snapshotColumns(table, s)
+	chunker := &tableChunker{
+		db:        s.Db,
+		table:     s.Table,
+		columns:   columns,
+		chunkSize: chunkSize,
+		filter:    filter,
+	}
+	
+	for _, name := range paginationColumns(table, s) {
+		found := false
+		for x, column := range columns {
+			if column.Name == name {
+				chunker.keyNames = append(chunker.keyNames, name)
+				chunker.keyIndex = append(chunker.keyIndex, x)
+				found = true
+				break
+			}
+		}
+		
+		if !found {
+			return nil, fmt.Errorf("%s.%s 分页列 %s 不在表结构中", s.Db, s.Table, name)
+		}
+	}
+	return chunker, nil
+}
+
+// snapshotColumns 全量同步需要读取的列: 写入文档的列, 以及主键、分页、filter、softDelete、jsonPaths、geo、vectors、关联列
+// 显式列出列名而不是 select *, 保证结果的列顺序与 canal 缓存的表结构一致
+
+func snapshotColumns(table *schema.Table, s *conf.Sync) []schema.TableColumn {
+	needed := make(map[string]bool)
+	need := func(names ...string) {
+		for _, name := range names {
+			if name != "" {
+				needed[strings.ToLower(name)] = true
+			}
+		}
+	}
+	
+	need(keyColumns(s)...)
+	need(paginationColumns(table, s)...)
+	need(filterColumns(s.Filter)...)
+	if softDelete := s.GetSoftDelete(); softDelete != nil {
+		need(softDelete.Column)
+	}
+	for _, jsonPath := range s.JsonPaths {
+		need(jsonPath.Column)
+	}
+	if geo := s.GetGeo(); geo != nil {
+		need(geo.Lat, geo.Lng, geo.Point)
+	}
+	for _, v := range s.Vectors {
+		need(v.Column)
+	}
+	for _, rel := range s.Relations {
+		need(relationParentKey(s, rel))
+	}
+	
+	var columns []schema.TableColumn
+	for _, column := range table.Columns {
+		if needed[strings.ToLower(column.Name)] || includeColumn(s, column.Name) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// paginationColumns 优先使用表的主键分页, 没有主键时使用同步配置中的主键列 (需要有唯一索引)
+
+func paginationColumns(table *schema.Table, s *conf.Sync) []string {
+	if len(table.PKColumns) == 0 {
+		return keyColumns(s)
+	}
+	
+	var names []string
+	for _, x := range table.PKColumns {
+		names = append(names, table.Columns[x].Name)
+	}
+	return names
+}
+
+// Query 返回下一批数据的 SQL
+
+func (chunker *tableChunker) Query() string {
+	selected := make([]string, 0, len(chunker.columns))
+	for _, column := range chunker.columns {
+		selected = append(selected, quoteName(column.Name))
+	}
+	
+	keys := make([]string, 0, len(chunker.keyNames))
+	for _, name := range chunker.keyNames {
+		keys = append(keys, quoteName(name))
+	}
+	
+	var conditions []string
+	if len(chunker.lastKey) > 0 {
+		conditions = append(conditions, fmt.Sprintf("(%s) > (%s)", strings.Join(keys, ", "), strings.Join(chunker.lastKey, ", ")))
+	} else if len(chunker.lowerKey) > 0 {
+		conditions = append(conditions, fmt.Sprintf("(%s) >= (%s)", strings.Join(keys, ", "), strings.Join(chunker.lowerKey, ", ")))
+	}
+	if len(chunker.upperKey) > 0 {
+		conditions = append(conditions, fmt.Sprintf("(%s) <= (%s)", strings.Join(keys, ", "), strings.Join(chunker.upperKey, ", ")))
+	}
+	if chunker.filter != "" {
+		conditions = append(conditions, "("+chunker.filter+")")
+	}
+	
+	var b strings.Builder
+	fmt.Fprintf(&b, "SELECT %s FROM %s.%s", strings.Join(selected, ", "), quoteName(chunker.db), quoteName(chunker.table))
+	if len(conditions) > 0 {
+		fmt.Fprintf(&b, " WHERE %s", strings.Join(conditions, " AND "))
+	}
+	fmt.Fprintf(&b, " ORDER BY %s LIMIT %d", strings.Join(keys, ", "), chunker.chunkSize)
+	return b.String()
+}
+
+// SetRange 只读取整数主键区间 [min, max] 内的数据
+
+func (chunker *tableChunker) SetRange(min, max int64) {
+	chunker.lowerKey = []string{strconv.FormatInt(min, 10)}
+	chunker.upperKey = []string{strconv.FormatInt(max, 10)}
+}
+
+// Advance 记录本批最后一行的分页列, 返回是否还有下一批
+
+func (chunker *tableChunker) Advance(r *mysql.Result) bool {
+	n := r.RowNumber()
+	if n == 0 {
+		return false
+	}
+	
+	last := r.Values[n-1]
+	lastKey := make([]string, 0, len(chunker.keyIndex))
+	for _, x := range chunker.keyIndex {
+		lastKey = append(lastKey, sqlLiteral(chunker.columns[x], last[x]))
+	}
+	chunker.lastKey = lastKey
+	
+	return n >= chunker.chunkSize
+}
+
+// Resume 从上一次全量同步记录的分页列之后继续读取, 分页列数量不一致时 (表结构变化) 返回 false
+
+func (chunker *tableChunker) Resume(lastKey []string) bool {
+	if len(lastKey) != len(chunker.keyNames) {
+		return false
+	}
+	
+	chunker.lastKey = lastKey
+	return true
+}
+
+// LastKey 上一批最后一行分页列的 SQL 字面量
+
+func (chunker *tableChunker) LastKey() []string {
+	return chunker.lastKey
+}
+
+// sqlLiteral 将文本协议返回的值转换为 SQL 字面量
+// 二进制列使用 _binary X'..' 避免字符集转换, 其余列使用转义后的字符串, 比较时使用列本身的排序规则
+
+func sqlLiteral(column schema.TableColumn, value mysql.FieldValue) string {
+	switch value.Type {
+	case mysql.FieldValueTypeNull:
+		return "NULL"
+	case mysql.FieldValueTypeUnsigned:
+		return fmt.Sprintf("%d", value.AsUint64())
+	case mysql.FieldValueTypeSigned:
+		return fmt.Sprintf("%d", value.AsInt64())
+	case mysql.FieldValueTypeFloat:
+		return strconv.FormatFloat(value.AsFloat64(), 'g', -1, 64)
+	}
+	
+	return bytesLiteral(column, value.AsString())
+}
+
+// valueLiteral 将 binlog 中的值转换为 SQL 字面量
+
+func valueLiteral(column schema.TableColumn, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case int8, int16, int32, int64, int, uint8, uint16, uint32, uint64, uint:
+		return fmt.Sprintf("%d", v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []byte:
+		return bytesLiteral(column, v)
+	case string:
+		return bytesLiteral(column, []byte(v))
+	default:
+		return bytesLiteral(column, []byte(fmt.Sprint(v)))
+	}
+}
+
+func bytesLiteral(column schema.TableColumn, b []byte) string {
+	if column.Type == schema.TYPE_BINARY || strings.HasSuffix(column.RawType, "blob") {
+		// binlog 中 BINARY(n) 会去掉末尾的 0x00
+		if uint(len(b)) < column.FixedSize {
+			b = append(append([]byte{}, b...), make([]byte, int(column.FixedSize)-len(b))...)
+		}
+		return fmt.Sprintf("_binary X'%s'", hex.EncodeToString(b))
+	}
+	return fmt.Sprintf("'%s'", mysql.Escape(string(b)))
+}
+
+// keyLiterals 返回一行数据中同步配置主键列的 SQL 字面量, 用于按主键重新读取该行
+
+func keyLiterals(tableColumns []schema.TableColumn, row []interface{}, s *conf.Sync) ([]string, error) {
+	var literals []string
+	for _, name := range keyColumns(s) {
+		found := false
+		for x, column := range tableColumns {
+			if column.Name == name && x < len(row) {
+				literals = append(literals, valueLiteral(column, row[x]))
+				found = true
+				break
+			}
+		}
+		
+		if !found {
+			return nil, fmt.Errorf("主键列 %s %w", name, errIdentifierNotFound)
+		}
+	}
+	return literals, nil
+}
+
+func quoteName(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
@@ -0,0 +1,427 @@
+package mysqlReplica
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 列值转换方式, 通过同步配置中的 converters 按列指定, 为空时按列类型使用默认方式
+// binlog 与全量读取 (文本协议) 返回的值类型不同, 统一转换后两种方式写入的文档一致
+
+const (
+	convertAuto        = ""
+	convertString      = "string"
+	convertNumber      = "number"
+	convertFloat       = "float"
+	convertBool        = "bool"
+	convertRFC3339     = "rfc3339"
+	convertEpoch       = "epoch"
+	convertEpochMillis = "epochMillis"
+	convertSeconds     = "seconds"
+	convertBase64      = "base64"
+	convertHex         = "hex"
+	convertObject      = "object"
+	convertSkip        = "skip"
+)
+
+// JSON 中可以精确表示的最大整数 2^53, 超过时默认转为字符串
+
+const maxSafeInteger = 1 << 53
+
+// 时间列在 binlog 与文本协议中的格式, 小数部分 0 ~ 6 位
+
+const (
+	mysqlDatetimeLayout = "2006-01-02 15:04:05.999999"
+	mysqlDateLayout     = "2006-01-02"
+)
+
+// columnConverters 每种列类型支持的转换方式, 第一个为默认方式
+
+var columnConverters = map[int][]string{
+	schema.TYPE_NUMBER:     {convertAuto, convertNumber, convertString},
+	schema.TYPE_MEDIUM_INT: {convertAuto, convertNumber, convertString},
+	schema.TYPE_FLOAT:      {convertAuto, convertFloat, convertString},
+	schema.TYPE_DECIMAL:    {convertAuto, convertString, convertFloat},
+	schema.TYPE_DATETIME:   {convertAuto, convertRFC3339, convertEpoch, convertEpochMillis, convertString},
+	schema.TYPE_TIMESTAMP:  {convertAuto, convertRFC3339, convertEpoch, convertEpochMillis, convertString},
+	schema.TYPE_DATE:       {convertAuto, convertString, convertRFC3339, convertEpoch, convertEpochMillis},
+	schema.TYPE_TIME:       {convertAuto, convertString, convertSeconds},
+	schema.TYPE_BIT:        {convertAuto, convertNumber, convertBool},
+	schema.TYPE_ENUM:       {convertAuto, convertString},
+	schema.TYPE_SET:        {convertAuto, convertString},
+	schema.TYPE_JSON:       {convertAuto, convertString, convertObject},
+	schema.TYPE_STRING:     {convertAuto, convertString, convertBase64, convertHex},
+	schema.TYPE_BINARY:     {convertAuto, convertBase64, convertHex, convertString},
+	schema.TYPE_POINT:      {convertAuto, convertBase64, convertHex},
+}
+
+// checkConverters 校验 converters 配置中的转换方式
+
+func checkConverters(s *conf.Sync) error {
+	for column, converter := range s.Converters {
+		switch converter {
+		case convertString, convertNumber, convertFloat, convertBool, convertRFC3339, convertEpoch,
+			convertEpochMillis, convertSeconds, convertBase64, convertHex, convertObject, convertSkip:
+		default:
+			return fmt.Errorf("%s.%s 列 %s 未知的转换方式: %s", s.Db, s.Table, column, converter)
+		}
+	}
+	return nil
+}
+
+// supportsConverter 列类型是否支持该转换方式, 不支持时使用默认方式
+
+func supportsConverter(column schema.TableColumn, converter string) bool {
+	if converter == convertSkip {
+		return true
+	}
+	for _, c := range columnConverters[column.Type] {
+		if c == converter {
+			return true
+		}
+	}
+	return false
+}
+
+// convertColumn 将 binlog 或文本协议返回的列值转换为 JSON 值, 返回 false 时文档中不写入该列
+
+func convertColumn(column schema.TableColumn, value interface{}, converter string) (interface{}, bool) {
+	if !supportsConverter(column, converter) {
+		converter = convertAuto
+	}
+	if converter == convertSkip {
+		return nil, false
+	}
+	if value == nil {
+		return nil, true
+	}
+	
+	switch column.Type {
+	case schema.TYPE_NUMBER, schema.TYPE_MEDIUM_INT:
+		return convertInteger(value, converter), true
+	case schema.TYPE_FLOAT:
+		return convertFloatValue(value, converter), true
+	case schema.TYPE_DECIMAL:
+		return convertDecimal(value, converter), true
+	case schema.TYPE_DATETIME, schema.TYPE_TIMESTAMP, schema.TYPE_DATE:
+		return convertTime(column, value, converter), true
+	case schema.TYPE_TIME:
+		return convertDuration(value, converter), true
+	case schema.TYPE_BIT:
+		return convertBit(value, converter), true
+	case schema.TYPE_ENUM:
+		return enumLabel(column, value), true
+	case schema.TYPE_SET:
+		return setLabels(column, value, converter), true
+	case schema.TYPE_JSON:
+		// object 解析为嵌套对象, 可以按内部字段过滤与搜索
+		if converter == convertObject {
+			if decoded, ok := decodeJSON(value); ok {
+				return decoded, true
+			}
+		}
+	case schema.TYPE_BINARY, schema.TYPE_POINT:
+		return convertBytes(column, value, converter)
+	case schema.TYPE_STRING:
+		if isBlobColumn(column) {
+			return convertBytes(column, value, converter)
+		}
+	}
+	
+	switch v := value.(type) {
+	case []byte:
+		return string(v), true
+	default:
+		return v, true
+	}
+}
+
+func isBlobColumn(column schema.TableColumn) bool {
+	return strings.HasSuffix(column.RawType, "blob")
+}
+
+// convertInteger 超过 2^53 的整数默认转为字符串, 避免 JSON 解析时丢失精度
+
+func convertInteger(value interface{}, converter string) interface{} {
+	var signed int64
+	var unsigned uint64
+	isUnsigned := false
+	
+	switch v := value.(type) {
+	case int8:
+		signed = int64(v)
+	case int16:
+		signed = int64(v)
+	case int32:
+		signed = int64(v)
+	case int64:
+		signed = v
+	case int:
+		signed = int64(v)
+	case uint8:
+		unsigned, isUnsigned = uint64(v), true
+	case uint16:
+		unsigned, isUnsigned = uint64(v), true
+	case uint32:
+		unsigned, isUnsigned = uint64(v), true
+	case uint64:
+		unsigned, isUnsigned = v, true
+	case uint:
+		unsigned, isUnsigned = uint64(v), true
+	default:
+		s := valueString(v)
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			u, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				return s
+			}
+			unsigned, isUnsigned = u, true
+		} else {
+			signed = n
+		}
+	}
+	
+	if isUnsigned {
+		if converter == convertString || converter == convertAuto && unsigned > maxSafeInteger {
+			return strconv.FormatUint(unsigned, 10)
+		}
+		return unsigned
+	}
+	
+	if converter == convertString || converter == convertAuto && (signed > maxSafeInteger || signed < -maxSafeInteger) {
+		return strconv.FormatInt(signed, 10)
+	}
+	return signed
+}
+
+func convertFloatValue(value interface{}, converter string) interface{} {
+	var f float64
+	switch v := value.(type) {
+	case float32:
+		f = float32Value(v)
+	case float64:
+		f = v
+	default:
+		n, err := strconv.ParseFloat(valueString(v), 64)
+		if err != nil {
+			return valueString(v)
+		}
+		f = n
+	}
+	
+	// NaN / Inf 无法写入 JSON
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	if converter == convertString {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return f
+}
+
+// float32Value binlog 中 FLOAT 列为 float32, 直接转换为 float64 会带上多余的尾数 (1.1 -> 1.100000023841858),
+// 按 float32 的最短表示格式化后再解析, 与全量读取返回的文本一致
+
+func float32Value(v float32) float64 {
+	f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+	return f
+}
+
+// convertDecimal 默认保持字符串, 避免精度丢失
+
+func convertDecimal(value interface{}, converter string) interface{} {
+	s := valueString(value)
+	if converter != convertFloat {
+		return s
+	}
+	
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+	return f
+}
+
+// convertTime DATETIME 按 UTC 解析, TIMESTAMP 在 binlog 与全量读取中都按 UTC 格式化
+// DATETIME / TIMESTAMP 默认转为 RFC3339, DATE 默认保持 2006-01-02, 零值转为 null
+
+func convertTime(column schema.TableColumn, value interface{}, converter string) interface{} {
+	if converter == convertAuto {
+		converter = columnConverters[column.Type][1]
+	}
+	
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	default:
+		s := valueString(v)
+		if strings.HasPrefix(s, "0000-00-00") {
+			return nil
+		}
+		if converter == convertString {
+			return s
+		}
+		
+		layout := mysqlDatetimeLayout
+		if len(s) <= len(mysqlDateLayout) {
+			layout = mysqlDateLayout
+		}
+		
+		parsed, err := time.ParseInLocation(layout, s, time.UTC)
+		if err != nil {
+			return s
+		}
+		t = parsed
+	}
+	
+	switch converter {
+	case convertRFC3339:
+		return t.UTC().Format(time.RFC3339Nano)
+	case convertEpoch:
+		return t.Unix()
+	case convertEpochMillis:
+		return t.UnixMilli()
+	}
+	
+	if column.Type == schema.TYPE_DATE {
+		return t.Format(mysqlDateLayout)
+	}
+	return t.Format(mysqlDatetimeLayout)
+}
+
+// convertDuration TIME 默认保持字符串 (可能为负数或超过 24 小时), seconds 转为秒数
+
+func convertDuration(value interface{}, converter string) interface{} {
+	s := valueString(value)
+	if converter != convertSeconds {
+		return s
+	}
+	
+	negative := strings.HasPrefix(s, "-")
+	parts := strings.Split(strings.TrimPrefix(s, "-"), ":")
+	if len(parts) != 3 {
+		return s
+	}
+	
+	var seconds float64
+	for _, part := range parts {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return s
+		}
+		seconds = seconds*60 + n
+	}
+	
+	if negative {
+		seconds = -seconds
+	}
+	if seconds == math.Trunc(seconds) {
+		return int64(seconds)
+	}
+	return seconds
+}
+
+// convertBit binlog 中为整数, 文本协议中为大端字节
+
+func convertBit(value interface{}, converter string) interface{} {
+	var n uint64
+	switch v := value.(type) {
+	case []byte:
+		b := make([]byte, 8)
+		if len(v) > 8 {
+			v = v[len(v)-8:]
+		}
+		copy(b[8-len(v):], v)
+		n = binary.BigEndian.Uint64(b)
+	case string:
+		return convertBit([]byte(v), converter)
+	case int64:
+		n = uint64(v)
+	default:
+		u, err := strconv.ParseUint(valueString(v), 10, 64)
+		if err != nil {
+			return valueString(v)
+		}
+		n = u
+	}
+	
+	if converter == convertBool {
+		return n != 0
+	}
+	return n
+}
+
+// enumLabel binlog 中为从 1 开始的序号, 文本协议中为标签
+
+func enumLabel(column schema.TableColumn, value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		if v >= 1 && int(v) <= len(column.EnumValues) {
+			return column.EnumValues[v-1]
+		}
+		return ""
+	default:
+		return valueString(v)
+	}
+}
+
+// setLabels binlog 中为位图, 文本协议中为逗号分隔的标签, 默认转为标签数组
+
+func setLabels(column schema.TableColumn, value interface{}, converter string) interface{} {
+	labels := []string{}
+	switch v := value.(type) {
+	case int64:
+		for x, label := range column.SetValues {
+			if x < 64 && v&(1<<uint(x)) != 0 {
+				labels = append(labels, label)
+			}
+		}
+	default:
+		if s := valueString(v); s != "" {
+			labels = strings.Split(s, ",")
+		}
+	}
+	
+	if converter == convertString {
+		return strings.Join(labels, ",")
+	}
+	return labels
+}
+
+// convertBytes 二进制列默认转为 base64
+// binlog 中 BINARY(n) 会去掉末尾的 0x00, 补齐后与全量读取一致
+
+func convertBytes(column schema.TableColumn, value interface{}, converter string) (interface{}, bool) {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return v, true
+	}
+	
+	if column.Type == schema.TYPE_BINARY && uint(len(b)) < column.FixedSize {
+		b = append(append([]byte{}, b...), make([]byte, int(column.FixedSize)-len(b))...)
+	}
+	
+	switch converter {
+	case convertAuto, convertBase64:
+		return base64.StdEncoding.EncodeToString(b), true
+	case convertHex:
+		return hex.EncodeToString(b), true
+	default:
+		return string(b), true
+	}
+}
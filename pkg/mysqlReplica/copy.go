@@ -0,0 +1,454 @@
+package mysqlReplica
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/canal"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"github.com/qx66/mysql-meilisearch/pkg/meilisearch"
+	"go.uber.org/zap"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// 估算行数超过该值的表拆分为多个主键区间并行读取
+	defaultSnapshotRangeRows = 1000000
+	// 每个 worker 最多分配的区间数, 避免区间过多
+	maxRangesPerWorker = 4
+)
+
+// copyTask 全量同步任务, 一个 worker 读取一张表或者一张表的一个主键区间写入 index
+
+type copyTask struct {
+	sync          *conf.Sync
+	index         string
+	client        *meilisearch.Client
+	table         *schema.Table
+	snapshot      *TableSnapshot
+	keyRange      *KeyRange
+	estimatedRows int64
+}
+
+// copyTarget 返回表数据写入的 index 以及记录进度的 TableSnapshot
+
+type copyTarget func(s *conf.Sync) (string, *TableSnapshot)
+
+// planCopyTasks 为每张未完成的表生成全量同步任务, 估算行数超过 rangeRows 且使用整数主键的表拆分为多个区间
+
+func (eventHandler *EventHandler) planCopyTasks(c *canal.Canal, executor Executor, concurrency int, syncs []*conf.Sync, target copyTarget) ([]*copyTask, error) {
+	rangeRows := eventHandler.snapshotConf.GetRangeRows()
+	if rangeRows <= 0 {
+		rangeRows = defaultSnapshotRangeRows
+	}
+	
+	var tasks []*copyTask
+	for _, s := range syncs {
+		// 获取 table 信息
+		table, err := c.GetTable(s.Db, s.Table)
+		if err != nil {
+			eventHandler.logger.Error(
+				"初始化数据库表失败, 获取表信息失败",
+				zap.String("database", s.Db),
+				zap.String("table", s.Table),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+		
+		eventHandler.Lock()
+		index, snapshot := target(s)
+		done, lastKey, ranges := snapshot.Done, snapshot.LastKey, snapshot.Ranges
+		eventHandler.Unlock()
+		
+		if done {
+			eventHandler.logger.Info(
+				"数据库表已完成初始化, 跳过",
+				zap.String("database", s.Db),
+				zap.String("table", s.Table),
+			)
+			continue
+		}
+		
+		estimatedRows := estimateTableRows(executor, s.Db, s.Table)
+		
+		// 第一次读取该表时决定是否拆分, 之后沿用 checkpoint 中记录的区间
+		if len(ranges) == 0 && len(lastKey) == 0 && concurrency > 1 && estimatedRows > rangeRows {
+			count := int((estimatedRows + rangeRows - 1) / rangeRows)
+			if count > concurrency*maxRangesPerWorker {
+				count = concurrency * maxRangesPerWorker
+			}
+			
+			ranges, err = splitKeyRanges(executor, table, s, count)
+			if err != nil {
+				eventHandler.logger.Error(
+					"初始化数据库表失败, 拆分主键区间失败",
+					zap.String("database", s.Db),
+					zap.String("table", s.Table),
+					zap.Error(err),
+				)
+				return nil, err
+			}
+			
+			eventHandler.Lock()
+			snapshot.Ranges = ranges
+			eventHandler.Unlock()
+			
+			if len(ranges) > 0 {
+				eventHandler.logger.Info(
+					"按主键区间并行初始化数据库表",
+					zap.String("database", s.Db),
+					zap.String("table", s.Table),
+					zap.Int64("estimatedRows", estimatedRows),
+					zap.Int("ranges", len(ranges)),
+				)
+			}
+		}
+		
+		if len(ranges) == 0 {
+			tasks = append(tasks, &copyTask{
+				sync:          s,
+				index:         index,
+				client:        eventHandler.meiliSearchClient,
+				table:         table,
+				snapshot:      snapshot,
+				estimatedRows: estimatedRows,
+			})
+			continue
+		}
+		
+		for _, keyRange := range ranges {
+			if keyRange.Done {
+				continue
+			}
+			
+			tasks = append(tasks, &copyTask{
+				sync:          s,
+				index:         index,
+				client:        eventHandler.meiliSearchClient,
+				table:         table,
+				snapshot:      snapshot,
+				keyRange:      keyRange,
+				estimatedRows: estimatedRows / int64(len(ranges)),
+			})
+		}
+	}
+	
+	return tasks, nil
+}
+
+// runCopyTasks 启动与 executors 数量相同的 worker 执行全量同步任务, 任意一个任务失败时停止其余任务
+// tick 不为空时每隔 snapshotCheckpointInterval 调用一次, 返回错误时同样停止
+
+func (eventHandler *EventHandler) runCopyTasks(ctx context.Context, executors []Executor, tasks []*copyTask, tick func() error) error {
+	taskCh := make(chan *copyTask, len(tasks))
+	for _, task := range tasks {
+		taskCh <- task
+	}
+	close(taskCh)
+	
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	
+	var errOnce sync.Once
+	var firstErr error
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+		})
+		cancel()
+	}
+	
+	var wg sync.WaitGroup
+	for _, executor := range executors {
+		wg.Add(1)
+		go func(executor Executor) {
+			defer wg.Done()
+			for task := range taskCh {
+				if ctx.Err() != nil {
+					return
+				}
+				
+				err := eventHandler.copyTable(ctx, executor, task)
+				if err != nil {
+					fail(err)
+					return
+				}
+			}
+		}(executor)
+	}
+	
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	
+	ticker := time.NewTicker(snapshotCheckpointInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-done:
+			if firstErr != nil {
+				return firstErr
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			if tick == nil {
+				continue
+			}
+			
+			err := tick()
+			if err != nil {
+				fail(err)
+			}
+		}
+	}
+}
+
+// splitKeyRanges 按单列整数主键的最小值/最大值将表平均拆分为 count 个区间, 不满足条件时返回 nil
+
+func splitKeyRanges(executor Executor, table *schema.Table, s *conf.Sync, count int) ([]*KeyRange, error) {
+	keys := paginationColumns(table, s)
+	if len(keys) != 1 || count < 2 {
+		return nil, nil
+	}
+	
+	column := table.FindColumn(keys[0])
+	if column < 0 {
+		return nil, nil
+	}
+	if columnType := table.Columns[column].Type; columnType != schema.TYPE_NUMBER && columnType != schema.TYPE_MEDIUM_INT {
+		return nil, nil
+	}
+	
+	r, err := executor.Execute(fmt.Sprintf("SELECT MIN(%s), MAX(%s) FROM %s.%s", quoteName(keys[0]), quoteName(keys[0]), quoteName(s.Db), quoteName(s.Table)))
+	if err != nil {
+		return nil, err
+	}
+	
+	min, ok := int64Value(r.Values[0][0].Value())
+	if !ok {
+		return nil, nil
+	}
+	max, ok := int64Value(r.Values[0][1].Value())
+	if !ok {
+		return nil, nil
+	}
+	
+	// 使用无符号数计算区间跨度, 避免 max - min 溢出
+	span := uint64(max) - uint64(min)
+	step := span/uint64(count) + 1
+	
+	var ranges []*KeyRange
+	for offset := uint64(0); offset <= span; offset += step {
+		keyRange := &KeyRange{
+			Min: int64(uint64(min) + offset),
+			Max: max,
+		}
+		if span-offset >= step {
+			keyRange.Max = int64(uint64(min) + offset + step - 1)
+		}
+		ranges = append(ranges, keyRange)
+		
+		if span-offset < step {
+			break
+		}
+	}
+	return ranges, nil
+}
+
+// int64Value 空表 (NULL) 或超过 int64 范围的无符号主键返回 false
+
+func int64Value(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// copyTable 按主键顺序分批读取表 (区间) 数据并写入 MeiliSearch
+
+func (eventHandler *EventHandler) copyTable(ctx context.Context, executor Executor, task *copyTask) error {
+	s := task.sync
+	chunker, err := newTableChunker(task.table, s, int(eventHandler.snapshotConf.GetChunkSize()))
+	if err != nil {
+		eventHandler.logger.Error(
+			"初始化数据库表失败, 获取分页列失败",
+			zap.String("database", s.Db),
+			zap.String("table", s.Table),
+			zap.Error(err),
+		)
+		return err
+	}
+	
+	fields := []zap.Field{
+		zap.String("database", s.Db),
+		zap.String("table", s.Table),
+		zap.String("index", task.index),
+	}
+	
+	eventHandler.RLock()
+	rows, lastKey := task.snapshot.Rows, task.snapshot.LastKey
+	if task.keyRange != nil {
+		chunker.SetRange(task.keyRange.Min, task.keyRange.Max)
+		rows, lastKey = task.keyRange.Rows, task.keyRange.LastKey
+		fields = append(fields, zap.Int64("rangeMin", task.keyRange.Min), zap.Int64("rangeMax", task.keyRange.Max))
+	}
+	eventHandler.RUnlock()
+	
+	if len(lastKey) > 0 {
+		if chunker.Resume(lastKey) {
+			eventHandler.logger.Info(
+				"继续上一次未完成的数据库表初始化",
+				append(fields, zap.Int64("rows", rows), zap.Strings("lastKey", lastKey))...,
+			)
+		} else {
+			eventHandler.logger.Warn(
+				"分页列与上一次不一致, 重新初始化数据库表",
+				append(fields, zap.Strings("lastKey", lastKey))...,
+			)
+			rows = 0
+		}
+	}
+	
+	primaryKey := documentPrimaryKey(s)
+	startTime := time.Now()
+	
+	// 获取 table 数据
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		
+		sql := chunker.Query()
+		r, err := executor.Execute(sql)
+		if err != nil {
+			eventHandler.logger.Error(
+				"初始化数据库表失败,执行SQL失败",
+				append(fields, zap.String("sql", sql), zap.Error(err))...,
+			)
+			return err
+		}
+		
+		if len(r.Values) == 0 {
+			break
+		}
+		
+		rowValues := make([][]interface{}, 0, len(r.Values))
+		for _, v := range r.Values {
+			row := make([]interface{}, len(v))
+			for n, x := range v {
+				row[n] = x.Value()
+			}
+			rowValues = append(rowValues, row)
+		}
+		
+		// 同一个连接读取子表, consistent/lock 模式下与父表在同一个快照中
+		docs, err := eventHandler.buildDocs(executor, chunker.columns, rowValues, s)
+		if err != nil {
+			eventHandler.logger.Error(
+				"初始化数据库表失败, 转换文档失败",
+				append(fields, zap.Error(err))...,
+			)
+			return err
+		}
+		
+		err = task.client.CreateDocs(task.index, docs, primaryKey)
+		if err != nil {
+			eventHandler.logger.Error(
+				"初始化数据库表失败, 插入数据到 MeiliSearch失败",
+				append(fields, zap.String("sql", sql), zap.Error(err))...,
+			)
+			return err
+		}
+		
+		rows += int64(len(docs))
+		more := chunker.Advance(r)
+		
+		// 任务提交之后再记录进度, FlushCheckpoint 写入的进度对应的任务都已经提交
+		eventHandler.Lock()
+		task.setProgress(rows, chunker.LastKey(), false)
+		eventHandler.Unlock()
+		
+		eventHandler.logger.Info(
+			"初始化数据库表进度",
+			append(fields,
+				zap.Int64("rows", rows),
+				zap.Int64("estimatedRows", task.estimatedRows),
+				zap.Strings("lastKey", chunker.LastKey()),
+				zap.Duration("elapsed", time.Since(startTime)),
+			)...,
+		)
+		
+		if !more {
+			break
+		}
+	}
+	
+	eventHandler.Lock()
+	tableDone := task.setProgress(rows, nil, true)
+	tableRows := task.snapshot.Rows
+	eventHandler.Unlock()
+	
+	if tableDone {
+		eventHandler.logger.Info(
+			"初始化数据库表成功",
+			zap.String("database", s.Db),
+			zap.String("table", s.Table),
+			zap.Int64("rows", tableRows),
+		)
+	}
+	return nil
+}
+
+// setProgress 更新任务进度, 区间任务同时汇总表的行数, 返回整张表是否已完成, 调用时需要持有锁
+
+func (task *copyTask) setProgress(rows int64, lastKey []string, done bool) bool {
+	snapshot := task.snapshot
+	snapshot.UpdatedAt = time.Now()
+	
+	if task.keyRange == nil {
+		snapshot.Rows = rows
+		snapshot.LastKey = lastKey
+		snapshot.Done = done
+		return done
+	}
+	
+	task.keyRange.Rows = rows
+	task.keyRange.LastKey = lastKey
+	task.keyRange.Done = done
+	
+	snapshot.Rows = 0
+	snapshot.Done = true
+	for _, keyRange := range snapshot.Ranges {
+		snapshot.Rows += keyRange.Rows
+		snapshot.Done = snapshot.Done && keyRange.Done
+	}
+	return snapshot.Done
+}
+
+// estimateTableRows 通过 information_schema 估算表的行数, 获取失败时返回 0
+
+func estimateTableRows(executor Executor, db, table string) int64 {
+	r, err := executor.Execute("SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", db, table)
+	if err != nil || r.RowNumber() == 0 {
+		return 0
+	}
+	
+	estimatedRows, err := r.GetInt(0, 0)
+	if err != nil {
+		return 0
+	}
+	return estimatedRows
+}
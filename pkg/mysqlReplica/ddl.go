@@ -0,0 +1,389 @@
+package mysqlReplica
+
+import (
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+	// 解析 DDL 中的字面量 (DEFAULT 值等) 需要注册 driver, canal 同样依赖
+	_ "github.com/pingcap/tidb/parser/test_driver"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"go.uber.org/zap"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 多张表写入同一个 index 时, 文档中记录来源表的默认字段名
+
+const defaultSourceField = "_table"
+
+// 表被 DROP 时的处理方式
+
+const (
+	dropPolicyKeep   = "keep"
+	dropPolicyDelete = "delete"
+	dropPolicyFail   = "fail"
+)
+
+// 表被 RENAME 时的处理方式
+
+const (
+	renamePolicyFollow = "follow"
+	renamePolicyStop   = "stop"
+)
+
+// sharedIndex index 是否有多张表写入
+
+func (eventHandler *EventHandler) sharedIndex(index string) bool {
+	n := 0
+	for _, s := range eventHandler.sync {
+		if s.Index == index {
+			n++
+		}
+	}
+	return n > 1
+}
+
+// sourceField 返回文档中记录来源表的字段名, index 只有一张表写入时为空
+
+func (eventHandler *EventHandler) sourceField(s *conf.Sync) string {
+	if !eventHandler.sharedIndex(s.Index) {
+		return ""
+	}
+	if s.SourceField != "" {
+		return s.SourceField
+	}
+	return defaultSourceField
+}
+
+// sourceTable 返回同步配置中原始的表名 (db.table), 表被 RENAME 之后不变
+
+func (eventHandler *EventHandler) sourceTable(s *conf.Sync) string {
+	if source, ok := eventHandler.sources[s]; ok {
+		return source
+	}
+	return fmt.Sprintf("%s.%s", s.Db, s.Table)
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// handleDDL 解析 DDL 语句, 处理同步表的 TRUNCATE / DROP / RENAME
+// 提交的 Meilisearch 任务与之后的位置一起写入 checkpoint, 任务执行成功前不会推进 checkpoint
+
+func (eventHandler *EventHandler) handleDDL(q *replication.QueryEvent) error {
+	stmts, _, err := parser.New().Parse(string(q.Query), "", "")
+	if err != nil {
+		eventHandler.logger.Warn(
+			"解析 DDL 失败, 忽略",
+			zap.String("query", string(q.Query)),
+			zap.Error(err),
+		)
+		return nil
+	}
+	
+	for _, stmt := range stmts {
+		switch t := stmt.(type) {
+		case *ast.TruncateTableStmt:
+			err = eventHandler.truncateTable(tableSchema(t.Table, q), t.Table.Name.String())
+		
+		case *ast.DropTableStmt:
+			if t.IsView || t.TemporaryKeyword != ast.TemporaryNone {
+				continue
+			}
+			
+			for _, table := range t.Tables {
+				err = eventHandler.dropTable(tableSchema(table, q), table.Name.String())
+				if err != nil {
+					break
+				}
+			}
+		
+		case *ast.RenameTableStmt:
+			err = eventHandler.renameTables(q, t.TableToTables)
+		
+		// ALTER TABLE t RENAME [TO] t2 先处理改名, 再按最新的表结构更新
+		case *ast.AlterTableStmt:
+			table := t.Table
+			for _, spec := range t.Specs {
+				if err == nil && spec.Tp == ast.AlterTableRenameTable {
+					err = eventHandler.renameTables(q, []*ast.TableToTable{{OldTable: t.Table, NewTable: spec.NewTable}})
+					table = spec.NewTable
+				}
+			}
+			
+			if err == nil {
+				err = eventHandler.alterTable(tableSchema(table, q), table.Name.String(), t.Specs)
+			}
+		}
+		
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTablePolicies 校验 onDrop / onRename 配置
+
+func checkTablePolicies(s *conf.Sync) error {
+	switch s.OnDrop {
+	case "", dropPolicyKeep, dropPolicyDelete, dropPolicyFail:
+	default:
+		return fmt.Errorf("%s.%s 未知的 onDrop: %s", s.Db, s.Table, s.OnDrop)
+	}
+	
+	switch s.OnRename {
+	case "", renamePolicyFollow, renamePolicyStop:
+	default:
+		return fmt.Errorf("%s.%s 未知的 onRename: %s", s.Db, s.Table, s.OnRename)
+	}
+	return nil
+}
+
+// tableSchema DDL 中没有指定库名时使用当前库
+
+func tableSchema(table *ast.TableName, q *replication.QueryEvent) string {
+	if table.Schema.String() != "" {
+		return table.Schema.String()
+	}
+	return string(q.Schema)
+}
+
+// dropTable 按同步配置的 onDrop 处理表被删除
+// keep: 保留 index 与文档; delete: 删除 index, 共用 index 时只删除该表的文档; fail: 返回错误停止同步, 不推进 checkpoint
+
+func (eventHandler *EventHandler) dropTable(db, table string) error {
+	eventHandler.resyncLock.Lock()
+	defer eventHandler.resyncLock.Unlock()
+	
+	for _, s := range eventHandler.sync {
+		if s.Db != db || s.Table != table {
+			continue
+		}
+		
+		switch s.OnDrop {
+		case "", dropPolicyKeep:
+			eventHandler.logger.Warn(
+				"同步表被 DROP, 保留 index",
+				zap.String("database", db),
+				zap.String("table", table),
+				zap.String("index", s.Index),
+			)
+		
+		case dropPolicyDelete:
+			err := eventHandler.deleteTableDocuments(s)
+			if err != nil {
+				return err
+			}
+		
+		case dropPolicyFail:
+			return fmt.Errorf("同步表 %s.%s 被 DROP, 停止同步", db, table)
+		
+		default:
+			return fmt.Errorf("%s.%s 未知的 onDrop: %s", db, table, s.OnDrop)
+		}
+	}
+	return nil
+}
+
+// deleteTableDocuments index 只有该表写入时删除 index, 否则按来源表字段只删除该表的文档
+// resync 期间共用 index 时同时删除影子 index 中该表的文档; 删除 index 时取消 resync, 避免交换后影子 index 重新生效
+// 调用时需要持有 resyncLock
+
+func (eventHandler *EventHandler) deleteTableDocuments(s *conf.Sync) error {
+	job := eventHandler.resyncs[s.Index]
+	field := eventHandler.sourceField(s)
+	if field != "" {
+		indexes := []string{s.Index}
+		if job != nil && job.active {
+			indexes = append(indexes, job.shadow)
+		}
+		
+		for _, index := range indexes {
+			err := eventHandler.indexClient(job, index).DeleteDocumentsByFilter(index, fmt.Sprintf("%s = %s", field, strconv.Quote(eventHandler.sourceTable(s))))
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if job != nil {
+			job.cancelled = true
+			delete(eventHandler.resyncs, s.Index)
+			eventHandler.logger.Warn(
+				"同步表被 DROP, 取消 resync",
+				zap.String("index", job.index),
+				zap.String("shadow", job.shadow),
+			)
+		}
+		
+		// 删除 index 前先等待已提交的任务执行完成, 之后的任务不会再写入该 index
+		err := eventHandler.meiliSearchClient.Tracker().Wait(eventHandler.ctx, eventHandler.meiliSearchClient.Tracker().Take())
+		if err != nil {
+			return err
+		}
+		
+		err = eventHandler.meiliSearchClient.DeleteIndex(eventHandler.ctx, s.Index)
+		if err != nil {
+			return err
+		}
+	}
+	
+	eventHandler.logger.Info(
+		"同步表被 DROP, 删除对应文档",
+		zap.String("database", s.Db),
+		zap.String("table", s.Table),
+		zap.String("index", s.Index),
+		zap.String("sourceField", field),
+	)
+	return nil
+}
+
+// renameTables 按同步配置的 onRename 处理表改名
+// 在线改表工具 (gh-ost / pt-online-schema-change) 切换表时, 原表改名为 _<table>_del / _<table>_old,
+// 影子表 _<table>_gho / _<table>_new 改名为原表名, 这类改名不视为同步表改名, 继续按原表名同步
+
+func (eventHandler *EventHandler) renameTables(q *replication.QueryEvent, pairs []*ast.TableToTable) error {
+	targets := make(map[string]bool)
+	for _, pair := range pairs {
+		targets[fmt.Sprintf("%s.%s", tableSchema(pair.NewTable, q), pair.NewTable.Name.String())] = true
+	}
+	
+	for _, pair := range pairs {
+		oldDb, oldTable := tableSchema(pair.OldTable, q), pair.OldTable.Name.String()
+		newDb, newTable := tableSchema(pair.NewTable, q), pair.NewTable.Name.String()
+		
+		for _, s := range eventHandler.sync {
+			if s.Db != oldDb || s.Table != oldTable {
+				continue
+			}
+			
+			// 同一条语句中其它表改名为该表 (原子交换), 或者改名为在线改表工具的旧表名 (两步切换)
+			if targets[fmt.Sprintf("%s.%s", oldDb, oldTable)] || newDb == oldDb && isCutOverTable(oldTable, newTable) {
+				eventHandler.logger.Info(
+					"在线改表切换, 继续按原表名同步",
+					zap.String("database", oldDb),
+					zap.String("table", oldTable),
+					zap.String("renameTo", fmt.Sprintf("%s.%s", newDb, newTable)),
+				)
+				
+				// 新表的表结构为在线改表之后的表结构
+				err := eventHandler.refreshSchema(s, nil)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			
+			switch s.OnRename {
+			case "", renamePolicyFollow:
+				eventHandler.followTable(s, newDb, newTable)
+			
+			case renamePolicyStop:
+				return fmt.Errorf("同步表 %s.%s 改名为 %s.%s, 停止同步", oldDb, oldTable, newDb, newTable)
+			
+			default:
+				return fmt.Errorf("%s.%s 未知的 onRename: %s", oldDb, oldTable, s.OnRename)
+			}
+		}
+	}
+	return nil
+}
+
+// 在线改表工具切换时原表新名字中表名之后的部分
+
+var cutOverSuffix = regexp.MustCompile(`^(_\d{14})?_(del|old)$`)
+
+// isCutOverTable 是否为在线改表工具切换时原表的新名字
+// gh-ost: _<table>_del / _<table>_<时间戳>_del; pt-online-schema-change: _<table>_old
+
+func isCutOverTable(table, renameTo string) bool {
+	prefix := "_" + table
+	return strings.HasPrefix(renameTo, prefix) && cutOverSuffix.MatchString(renameTo[len(prefix):])
+}
+
+// followTable 按新表名继续同步, 改名与之后的位置一起写入 checkpoint
+
+func (eventHandler *EventHandler) followTable(s *conf.Sync, db, table string) {
+	from := fmt.Sprintf("%s.%s", s.Db, s.Table)
+	to := fmt.Sprintf("%s.%s", db, table)
+	
+	eventHandler.resyncLock.Lock()
+	s.Db = db
+	s.Table = table
+	eventHandler.resyncLock.Unlock()
+	
+	if t, ok := eventHandler.schemas[from]; ok {
+		delete(eventHandler.schemas, from)
+		eventHandler.schemas[to] = t
+	}
+	
+	eventHandler.renames = append(eventHandler.renames, TableRename{
+		Source: eventHandler.sourceTable(s),
+		From:   from,
+		To:     to,
+	})
+	
+	eventHandler.logger.Warn(
+		"同步表改名, 按新表名继续同步",
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.String("index", s.Index),
+	)
+}
+
+// truncateTable index 只有该表写入时清空 index, 否则按来源表字段只删除该表的文档
+// resync 期间同时清理影子 index, 打开的 backfill 窗口中读取到的数据全部丢弃
+
+func (eventHandler *EventHandler) truncateTable(db, table string) error {
+	eventHandler.resyncLock.Lock()
+	defer eventHandler.resyncLock.Unlock()
+	
+	for _, s := range eventHandler.sync {
+		if s.Db != db || s.Table != table {
+			continue
+		}
+		
+		indexes := []string{s.Index}
+		job := eventHandler.resyncs[s.Index]
+		if job != nil && job.active {
+			indexes = append(indexes, job.shadow)
+		}
+		
+		field := eventHandler.sourceField(s)
+		for _, index := range indexes {
+			var err error
+			if field == "" {
+				err = eventHandler.indexClient(job, index).DeleteAllDocuments(index)
+			} else {
+				err = eventHandler.indexClient(job, index).DeleteDocumentsByFilter(index, fmt.Sprintf("%s = %s", field, strconv.Quote(eventHandler.sourceTable(s))))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		
+		if window := eventHandler.backfillWindow(s); window != nil && window.open {
+			window.truncated = true
+		}
+		
+		eventHandler.logger.Info(
+			"TRUNCATE 表, 删除对应文档",
+			zap.String("database", db),
+			zap.String("table", table),
+			zap.Strings("indexes", indexes),
+			zap.String("sourceField", field),
+		)
+	}
+	return nil
+}
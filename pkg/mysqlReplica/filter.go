@@ -0,0 +1,786 @@
+package mysqlReplica
+
+import (
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/pingcap/tidb/parser/format"
+	"github.com/pingcap/tidb/parser/mysql"
+	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/parser/test_driver"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rowFilter 同步配置中的行过滤条件, 语法为 SQL WHERE 表达式
+// 全量读取时作为 WHERE 条件下推到 MySQL, binlog 同步时在行数据上计算
+
+type rowFilter struct {
+	expr ast.ExprNode
+	// 规范化之后的 SQL, 用于下推
+	sql string
+	// 模式为字面量的 LIKE 在解析时编译, 避免每一行都重新编译
+	likes map[*ast.PatternLikeExpr]*likePattern
+}
+
+// likePattern 编译后的 LIKE 模式, 按列的排序规则选择是否区分大小写
+
+type likePattern struct {
+	caseInsensitive *regexp.Regexp
+	caseSensitive   *regexp.Regexp
+}
+
+// 解析后的过滤条件, 按表达式缓存
+
+var rowFilters sync.Map
+
+// parseRowFilter 解析过滤条件, 只支持比较、逻辑运算、IS [NOT] NULL、IN、BETWEEN、LIKE
+
+func parseRowFilter(filter string) (*rowFilter, error) {
+	if cached, ok := rowFilters.Load(filter); ok {
+		return cached.(*rowFilter), nil
+	}
+	
+	stmt, err := parser.New().ParseOneStmt("SELECT 1 FROM t WHERE "+filter, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("解析 filter 失败: %w", err)
+	}
+	
+	sel, ok := stmt.(*ast.SelectStmt)
+	if !ok || sel.Where == nil || sel.GroupBy != nil || sel.Having != nil || sel.OrderBy != nil || sel.Limit != nil || sel.LockInfo != nil {
+		return nil, fmt.Errorf("filter 只能是 WHERE 条件表达式: %s", filter)
+	}
+	
+	err = checkFilterExpr(sel.Where)
+	if err != nil {
+		return nil, fmt.Errorf("filter %s: %w", filter, err)
+	}
+	
+	// 字符串字面量不带字符集前缀, 按连接字符集与列的排序规则比较
+	// test_driver 还原时忽略 RestoreStringWithoutCharset, 需要先清空默认的字符集
+	sel.Where.Accept(&charsetStripper{})
+	
+	var b strings.Builder
+	err = sel.Where.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags|format.RestoreStringWithoutCharset, &b))
+	if err != nil {
+		return nil, fmt.Errorf("filter %s: %w", filter, err)
+	}
+	
+	compiler := &likeCompiler{likes: make(map[*ast.PatternLikeExpr]*likePattern)}
+	sel.Where.Accept(compiler)
+	if compiler.err != nil {
+		return nil, fmt.Errorf("filter %s: %w", filter, compiler.err)
+	}
+	
+	f := &rowFilter{expr: sel.Where, sql: b.String(), likes: compiler.likes}
+	rowFilters.Store(filter, f)
+	return f, nil
+}
+
+// charsetStripper 清空字符串字面量的默认字符集, 显式指定的字符集 (例如 _latin1'a') 保留
+
+type charsetStripper struct{}
+
+func (stripper *charsetStripper) Enter(n ast.Node) (ast.Node, bool) {
+	if v, ok := n.(*test_driver.ValueExpr); ok && v.Kind() == test_driver.KindString && v.Type.GetCharset() == mysql.DefaultCharset {
+		v.Type.SetCharset("")
+	}
+	return n, false
+}
+
+func (stripper *charsetStripper) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// likeCompiler 编译模式为字面量的 LIKE
+
+type likeCompiler struct {
+	likes map[*ast.PatternLikeExpr]*likePattern
+	err   error
+}
+
+func (compiler *likeCompiler) Enter(n ast.Node) (ast.Node, bool) {
+	e, ok := n.(*ast.PatternLikeExpr)
+	if !ok || compiler.err != nil {
+		return n, false
+	}
+	
+	value, ok := e.Pattern.(ast.ValueExpr)
+	if !ok || value.GetValue() == nil {
+		return n, false
+	}
+	
+	pattern := toString(literalValue(value.GetValue()))
+	caseInsensitive, err := compileLike(pattern, e.Escape, false)
+	if err != nil {
+		compiler.err = err
+		return n, true
+	}
+	caseSensitive, err := compileLike(pattern, e.Escape, true)
+	if err != nil {
+		compiler.err = err
+		return n, true
+	}
+	
+	compiler.likes[e] = &likePattern{caseInsensitive: caseInsensitive, caseSensitive: caseSensitive}
+	return n, false
+}
+
+func (compiler *likeCompiler) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+func checkFilterExpr(expr ast.ExprNode) error {
+	switch e := expr.(type) {
+	case ast.ValueExpr:
+		return nil
+	case *ast.ColumnNameExpr:
+		if e.Name.Table.O != "" {
+			return fmt.Errorf("列名不能带表名: %s", e.Name.Name.O)
+		}
+		return nil
+	case *ast.ParenthesesExpr:
+		return checkFilterExpr(e.Expr)
+	case *ast.UnaryOperationExpr:
+		switch e.Op {
+		case opcode.Not, opcode.Not2, opcode.Minus, opcode.Plus:
+			return checkFilterExpr(e.V)
+		}
+		return fmt.Errorf("不支持的运算符: %s", e.Op)
+	case *ast.BinaryOperationExpr:
+		switch e.Op {
+		case opcode.LogicAnd, opcode.LogicOr, opcode.LogicXor,
+			opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE, opcode.NullEQ:
+		default:
+			return fmt.Errorf("不支持的运算符: %s", e.Op)
+		}
+		return checkFilterExprs(e.L, e.R)
+	case *ast.IsNullExpr:
+		return checkFilterExpr(e.Expr)
+	case *ast.IsTruthExpr:
+		return checkFilterExpr(e.Expr)
+	case *ast.PatternInExpr:
+		if e.Sel != nil {
+			return fmt.Errorf("IN 不支持子查询")
+		}
+		return checkFilterExprs(append([]ast.ExprNode{e.Expr}, e.List...)...)
+	case *ast.BetweenExpr:
+		return checkFilterExprs(e.Expr, e.Left, e.Right)
+	case *ast.PatternLikeExpr:
+		return checkFilterExprs(e.Expr, e.Pattern)
+	default:
+		return fmt.Errorf("不支持的表达式: %T", expr)
+	}
+}
+
+func checkFilterExprs(exprs ...ast.ExprNode) error {
+	for _, expr := range exprs {
+		err := checkFilterExpr(expr)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFilter 校验 filter 配置
+
+func checkFilter(s *conf.Sync) error {
+	if s.Filter == "" {
+		return nil
+	}
+	
+	_, err := parseRowFilter(s.Filter)
+	if err != nil {
+		return fmt.Errorf("%s.%s %w", s.Db, s.Table, err)
+	}
+	return nil
+}
+
+// filterSQL 返回下推到全量读取的 WHERE 条件, 没有配置 filter 时为空
+
+func filterSQL(s *conf.Sync) (string, error) {
+	if s.Filter == "" {
+		return "", nil
+	}
+	
+	f, err := parseRowFilter(s.Filter)
+	if err != nil {
+		return "", err
+	}
+	return f.sql, nil
+}
+
+// filterColumns 返回过滤条件中引用的列名, 解析失败时为空 (启动时已经由 checkFilter 校验)
+
+func filterColumns(filter string) []string {
+	if filter == "" {
+		return nil
+	}
+	
+	f, err := parseRowFilter(filter)
+	if err != nil {
+		return nil
+	}
+	
+	collector := &columnCollector{}
+	f.expr.Accept(collector)
+	return collector.names
+}
+
+type columnCollector struct {
+	names []string
+}
+
+func (collector *columnCollector) Enter(n ast.Node) (ast.Node, bool) {
+	if column, ok := n.(*ast.ColumnNameExpr); ok {
+		collector.names = append(collector.names, column.Name.Name.O)
+	}
+	return n, false
+}
+
+func (collector *columnCollector) Leave(n ast.Node) (ast.Node, bool) {
+	return n, true
+}
+
+// matchFilter 行数据是否满足 filter, 没有配置 filter 时全部满足; 与 MySQL 相同, 结果为 NULL 时不满足
+
+func matchFilter(s *conf.Sync, tableColumns []schema.TableColumn, row []interface{}) (bool, error) {
+	if s.Filter == "" {
+		return true, nil
+	}
+	
+	f, err := parseRowFilter(s.Filter)
+	if err != nil {
+		return false, err
+	}
+	
+	value, err := f.evalFilter(f.expr, tableColumns, row)
+	if err != nil {
+		return false, fmt.Errorf("%s.%s filter: %w", s.Db, s.Table, err)
+	}
+	
+	truth := filterTruth(value)
+	return truth != nil && *truth, nil
+}
+
+// evalFilter 计算表达式, 结果为 nil (NULL)、int64、uint64、float64、string、collatedString 或 time.Time, 布尔值为 1 / 0
+
+func (filter *rowFilter) evalFilter(expr ast.ExprNode, tableColumns []schema.TableColumn, row []interface{}) (interface{}, error) {
+	switch e := expr.(type) {
+	case ast.ValueExpr:
+		return literalValue(e.GetValue()), nil
+	
+	case *ast.ColumnNameExpr:
+		for x, column := range tableColumns {
+			if strings.EqualFold(column.Name, e.Name.Name.O) && x < len(row) {
+				return filterValue(column, row[x]), nil
+			}
+		}
+		return nil, fmt.Errorf("列 %s 不存在", e.Name.Name.O)
+	
+	case *ast.ParenthesesExpr:
+		return filter.evalFilter(e.Expr, tableColumns, row)
+	
+	case *ast.UnaryOperationExpr:
+		v, err := filter.evalFilter(e.V, tableColumns, row)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		
+		switch e.Op {
+		case opcode.Not, opcode.Not2:
+			return truthValue(not(filterTruth(v))), nil
+		case opcode.Minus:
+			return -toFloat(v), nil
+		}
+		return v, nil
+	
+	case *ast.BinaryOperationExpr:
+		l, err := filter.evalFilter(e.L, tableColumns, row)
+		if err != nil {
+			return nil, err
+		}
+		r, err := filter.evalFilter(e.R, tableColumns, row)
+		if err != nil {
+			return nil, err
+		}
+		
+		switch e.Op {
+		case opcode.LogicAnd:
+			return truthValue(and(filterTruth(l), filterTruth(r))), nil
+		case opcode.LogicOr:
+			return truthValue(not(and(not(filterTruth(l)), not(filterTruth(r))))), nil
+		case opcode.LogicXor:
+			lt, rt := filterTruth(l), filterTruth(r)
+			if lt == nil || rt == nil {
+				return nil, nil
+			}
+			return boolValue(*lt != *rt), nil
+		case opcode.NullEQ:
+			if l == nil || r == nil {
+				return boolValue(l == nil && r == nil), nil
+			}
+			return boolValue(compareValues(l, r) == 0), nil
+		}
+		
+		if l == nil || r == nil {
+			return nil, nil
+		}
+		
+		c := compareValues(l, r)
+		switch e.Op {
+		case opcode.EQ:
+			return boolValue(c == 0), nil
+		case opcode.NE:
+			return boolValue(c != 0), nil
+		case opcode.LT:
+			return boolValue(c < 0), nil
+		case opcode.LE:
+			return boolValue(c <= 0), nil
+		case opcode.GT:
+			return boolValue(c > 0), nil
+		default:
+			return boolValue(c >= 0), nil
+		}
+	
+	case *ast.IsNullExpr:
+		v, err := filter.evalFilter(e.Expr, tableColumns, row)
+		if err != nil {
+			return nil, err
+		}
+		return boolValue((v == nil) != e.Not), nil
+	
+	case *ast.IsTruthExpr:
+		v, err := filter.evalFilter(e.Expr, tableColumns, row)
+		if err != nil {
+			return nil, err
+		}
+		truth := filterTruth(v)
+		return boolValue((truth != nil && *truth == (e.True != 0)) != e.Not), nil
+	
+	case *ast.PatternInExpr:
+		v, err := filter.evalFilter(e.Expr, tableColumns, row)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		
+		var result interface{} = boolValue(false)
+		for _, item := range e.List {
+			candidate, err := filter.evalFilter(item, tableColumns, row)
+			if err != nil {
+				return nil, err
+			}
+			if candidate == nil {
+				result = nil
+				continue
+			}
+			if compareValues(v, candidate) == 0 {
+				result = boolValue(true)
+				break
+			}
+		}
+		if e.Not {
+			return truthValue(not(filterTruth(result))), nil
+		}
+		return result, nil
+	
+	case *ast.BetweenExpr:
+		v, err := filter.evalFilter(e.Expr, tableColumns, row)
+		if err != nil {
+			return nil, err
+		}
+		left, err := filter.evalFilter(e.Left, tableColumns, row)
+		if err != nil {
+			return nil, err
+		}
+		right, err := filter.evalFilter(e.Right, tableColumns, row)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil || left == nil || right == nil {
+			return nil, nil
+		}
+		return boolValue((compareValues(v, left) >= 0 && compareValues(v, right) <= 0) != e.Not), nil
+	
+	case *ast.PatternLikeExpr:
+		v, err := filter.evalFilter(e.Expr, tableColumns, row)
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := filter.evalFilter(e.Pattern, tableColumns, row)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil || pattern == nil {
+			return nil, nil
+		}
+		
+		caseSensitive := valueCollation(v, pattern).caseSensitive
+		re, err := filter.likeRegexp(e, toString(pattern), caseSensitive)
+		if err != nil {
+			return nil, err
+		}
+		return boolValue(re.MatchString(toString(v)) != e.Not), nil
+	}
+	return nil, fmt.Errorf("不支持的表达式: %T", expr)
+}
+
+// filterValue 将 binlog 或文本协议返回的列值统一为可以比较的值
+
+func filterValue(column schema.TableColumn, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	
+	switch column.Type {
+	case schema.TYPE_ENUM:
+		return enumLabel(column, value)
+	case schema.TYPE_SET:
+		return setLabels(column, value, convertString)
+	case schema.TYPE_BIT:
+		return convertBit(value, convertNumber)
+	case schema.TYPE_DECIMAL:
+		return toFloat(valueString(value))
+	case schema.TYPE_DATETIME, schema.TYPE_TIMESTAMP, schema.TYPE_DATE:
+		if t, ok := parseFilterTime(valueString(value)); ok {
+			return t
+		}
+		return nil
+	case schema.TYPE_STRING, schema.TYPE_BINARY:
+		return collatedString{value: valueString(value), collation: columnCollation(column)}
+	}
+	return literalValue(value)
+}
+
+// collation 字符串的比较规则, 零值与默认的 _ci 排序规则一致: 不区分大小写并忽略末尾空格
+
+type collation struct {
+	caseSensitive bool
+	// NO PAD 排序规则比较时不忽略末尾空格
+	noPad bool
+}
+
+// collatedString 字符串列的值, 比较时使用列的排序规则, 与 MySQL 相同列的排序规则优先于字面量
+
+type collatedString struct {
+	value     string
+	collation collation
+}
+
+// columnCollation 返回列的比较规则: 二进制字符串按字节比较, _bin / _cs 区分大小写,
+// MySQL 8.0 的 _0900_ 排序规则为 NO PAD
+
+func columnCollation(column schema.TableColumn) collation {
+	name := strings.ToLower(column.Collation)
+	if column.Type == schema.TYPE_BINARY || name == "binary" || name == "" && strings.Contains(column.RawType, "blob") {
+		return collation{caseSensitive: true, noPad: true}
+	}
+	
+	return collation{
+		caseSensitive: strings.HasSuffix(name, "_bin") || strings.HasSuffix(name, "_cs"),
+		noPad:         strings.Contains(name, "_0900_"),
+	}
+}
+
+// valueCollation 返回比较两个值时使用的规则, 优先使用列的排序规则
+
+func valueCollation(l, r interface{}) collation {
+	if v, ok := l.(collatedString); ok {
+		return v.collation
+	}
+	if v, ok := r.(collatedString); ok {
+		return v.collation
+	}
+	return collation{}
+}
+
+func stringValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case collatedString:
+		return v.value, true
+	}
+	return "", false
+}
+
+func compareStrings(l, r string, c collation) int {
+	if !c.noPad {
+		l = strings.TrimRight(l, " ")
+		r = strings.TrimRight(r, " ")
+	}
+	if !c.caseSensitive {
+		l = strings.ToLower(l)
+		r = strings.ToLower(r)
+	}
+	return strings.Compare(l, r)
+}
+
+func literalValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case uint8:
+		return uint64(v)
+	case uint16:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	case uint:
+		return uint64(v)
+	case uint64:
+		return v
+	case float32:
+		return float32Value(v)
+	case float64:
+		return v
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case fmt.Stringer:
+		// DECIMAL 字面量
+		return toFloat(v.String())
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// compareValues 按 MySQL 的规则比较: 一方为数字时按数字比较, 一方为时间时按时间比较,
+// 否则按列的排序规则比较字符串, 没有列时与默认的 _ci 排序规则一致
+
+func compareValues(l, r interface{}) int {
+	lt, lTime := l.(time.Time)
+	rt, rTime := r.(time.Time)
+	if lTime || rTime {
+		if !lTime {
+			lt, lTime = parseFilterTime(toString(l))
+		}
+		if !rTime {
+			rt, rTime = parseFilterTime(toString(r))
+		}
+		if lTime && rTime {
+			return lt.Compare(rt)
+		}
+		return strings.Compare(toString(l), toString(r))
+	}
+	
+	ls, lString := stringValue(l)
+	rs, rString := stringValue(r)
+	if lString && rString {
+		return compareStrings(ls, rs, valueCollation(l, r))
+	}
+	
+	// 整数之间直接比较, 避免超过 2^53 时丢失精度
+	switch lv := l.(type) {
+	case int64:
+		switch rv := r.(type) {
+		case int64:
+			return compareInt64(lv, rv)
+		case uint64:
+			if lv < 0 {
+				return -1
+			}
+			return compareUint64(uint64(lv), rv)
+		}
+	case uint64:
+		switch rv := r.(type) {
+		case uint64:
+			return compareUint64(lv, rv)
+		case int64:
+			if rv < 0 {
+				return 1
+			}
+			return compareUint64(lv, uint64(rv))
+		}
+	}
+	return compareFloat(toFloat(l), toFloat(r))
+}
+
+func compareInt64(l, r int64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+func compareUint64(l, r uint64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+func parseFilterTime(s string) (time.Time, bool) {
+	layout := mysqlDatetimeLayout
+	if len(s) <= len(mysqlDateLayout) {
+		layout = mysqlDateLayout
+	}
+	
+	t, err := time.ParseInLocation(layout, s, time.UTC)
+	if err != nil || strings.HasPrefix(s, "0000-00-00") {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case collatedString:
+		return v.value
+	case time.Time:
+		return v.Format(mysqlDatetimeLayout)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// toFloat 字符串按 MySQL 的规则取开头的数字部分, 无法解析时为 0
+
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64:
+		return v
+	case time.Time:
+		f, _ := strconv.ParseFloat(v.Format("20060102150405"), 64)
+		return f
+	}
+	
+	s := strings.TrimSpace(toString(value))
+	for end := len(s); end > 0; end-- {
+		f, err := strconv.ParseFloat(s[:end], 64)
+		if err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return f
+		}
+	}
+	return 0
+}
+
+// filterTruth 三值逻辑, nil 表示 NULL
+
+func filterTruth(value interface{}) *bool {
+	if value == nil {
+		return nil
+	}
+	
+	truth := toFloat(value) != 0
+	if t, ok := value.(time.Time); ok {
+		truth = !t.IsZero()
+	}
+	return &truth
+}
+
+func truthValue(truth *bool) interface{} {
+	if truth == nil {
+		return nil
+	}
+	return boolValue(*truth)
+}
+
+func boolValue(b bool) interface{} {
+	if b {
+		return int64(1)
+	}
+	return int64(0)
+}
+
+func not(truth *bool) *bool {
+	if truth == nil {
+		return nil
+	}
+	v := !*truth
+	return &v
+}
+
+func and(l, r *bool) *bool {
+	f := false
+	if l != nil && !*l || r != nil && !*r {
+		return &f
+	}
+	if l == nil || r == nil {
+		return nil
+	}
+	t := true
+	return &t
+}
+
+// likeRegexp 返回 LIKE 模式编译后的正则, 模式为字面量时使用解析时编译的结果
+
+func (filter *rowFilter) likeRegexp(e *ast.PatternLikeExpr, pattern string, caseSensitive bool) (*regexp.Regexp, error) {
+	if compiled, ok := filter.likes[e]; ok {
+		if caseSensitive {
+			return compiled.caseSensitive, nil
+		}
+		return compiled.caseInsensitive, nil
+	}
+	return compileLike(pattern, e.Escape, caseSensitive)
+}
+
+// compileLike 将 LIKE 模式编译为正则, % 匹配任意多个字符, _ 匹配单个字符
+
+func compileLike(pattern string, escape byte, caseSensitive bool) (*regexp.Regexp, error) {
+	flags := "(?is)^"
+	if caseSensitive {
+		flags = "(?s)^"
+	}
+	
+	var b strings.Builder
+	b.WriteString(flags)
+	runes := []rune(pattern)
+	for x := 0; x < len(runes); x++ {
+		switch c := runes[x]; {
+		case c == rune(escape) && x+1 < len(runes):
+			x++
+			b.WriteString(regexp.QuoteMeta(string(runes[x])))
+		case c == '%':
+			b.WriteString(".*")
+		case c == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
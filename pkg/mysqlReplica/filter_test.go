@@ -0,0 +1,262 @@
+package mysqlReplica
+
+import (
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"testing"
+)
+
+var filterColumnsFixture = []schema.TableColumn{
+	{Name: "id", Type: schema.TYPE_NUMBER},
+	{Name: "price", Type: schema.TYPE_DECIMAL},
+	{Name: "name", Type: schema.TYPE_STRING},
+	{Name: "status", Type: schema.TYPE_ENUM, EnumValues: []string{"active", "deleted"}},
+	{Name: "created", Type: schema.TYPE_DATETIME},
+	{Name: "note", Type: schema.TYPE_STRING},
+}
+
+// binlog 中 ENUM 为序号, DECIMAL 为字符串
+var filterRowFixture = []interface{}{int32(7), "12.50", "Alice ", int64(1), "2024-01-02 03:04:05", nil}
+
+func TestEvalFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		// true / false / null
+		want string
+	}{
+		{"id = 7", "true"},
+		{"id != 7", "false"},
+		{"id <> 8", "true"},
+		{"id < 8", "true"},
+		{"id <= 6", "false"},
+		{"id > 6", "true"},
+		{"id >= 8", "false"},
+		{"-id < 0", "true"},
+		{"id = '7'", "true"},
+		
+		// NULL 三值逻辑
+		{"note = 'x'", "null"},
+		{"note != 'x'", "null"},
+		{"note <=> NULL", "true"},
+		{"id <=> NULL", "false"},
+		{"note IS NULL", "true"},
+		{"note IS NOT NULL", "false"},
+		{"NOT (note = 'x')", "null"},
+		{"note = 'x' AND id = 8", "false"},
+		{"note = 'x' AND id = 7", "null"},
+		{"note = 'x' OR id = 7", "true"},
+		{"note = 'x' OR id = 8", "null"},
+		{"note = 'x' XOR id = 7", "null"},
+		{"id = 7 XOR id = 8", "true"},
+		{"(note = 'x') IS NULL", "true"},
+		{"(id = 7) IS TRUE", "true"},
+		{"(note = 'x') IS NOT FALSE", "true"},
+		
+		// DECIMAL 与字符串、数字比较时按数字比较
+		{"price = 12.5", "true"},
+		{"price = '12.5'", "true"},
+		{"price > 12.49", "true"},
+		{"price < '9'", "false"},
+		
+		// 字符串不区分大小写并忽略末尾空格
+		{"name = 'alice'", "true"},
+		{"name > 'Bob'", "false"},
+		
+		{"id IN (1, 7)", "true"},
+		{"id IN (1, 2)", "false"},
+		{"id IN (1, NULL)", "null"},
+		{"id NOT IN (1, 2)", "true"},
+		{"id NOT IN (1, NULL)", "null"},
+		{"note IN ('x')", "null"},
+		
+		{"id BETWEEN 1 AND 7", "true"},
+		{"id NOT BETWEEN 1 AND 7", "false"},
+		{"price BETWEEN '12' AND 13", "true"},
+		{"id BETWEEN NULL AND 7", "null"},
+		
+		{"name LIKE 'Al%'", "true"},
+		{"name LIKE '_lice '", "true"},
+		{"name NOT LIKE '%z%'", "true"},
+		{"name LIKE 'Al\\_%'", "false"},
+		{"note LIKE '%'", "null"},
+		
+		// ENUM 按标签比较
+		{"status = 'active'", "true"},
+		{"status IN ('deleted')", "false"},
+		
+		// 时间与字符串按时间比较
+		{"created > '2024-01-01'", "true"},
+		{"created = '2024-01-02 03:04:05'", "true"},
+		{"created BETWEEN '2024-01-02' AND '2024-01-03'", "true"},
+		{"created < '2023-12-31 23:59:59'", "false"},
+	}
+	
+	for _, test := range tests {
+		f, err := parseRowFilter(test.filter)
+		if err != nil {
+			t.Fatalf("parseRowFilter(%q): %v", test.filter, err)
+		}
+		
+		value, err := f.evalFilter(f.expr, filterColumnsFixture, filterRowFixture)
+		if err != nil {
+			t.Fatalf("evalFilter(%q): %v", test.filter, err)
+		}
+		
+		got := "null"
+		if truth := filterTruth(value); truth != nil {
+			got = "false"
+			if *truth {
+				got = "true"
+			}
+		}
+		if got != test.want {
+			t.Errorf("evalFilter(%q) = %s, want %s", test.filter, got, test.want)
+		}
+	}
+}
+
+func TestEvalFilterCollation(t *testing.T) {
+	columns := []schema.TableColumn{
+		{Name: "code", Type: schema.TYPE_STRING, Collation: "utf8mb4_bin"},
+		{Name: "tag", Type: schema.TYPE_STRING, Collation: "utf8mb4_0900_ai_ci"},
+		{Name: "raw", Type: schema.TYPE_BINARY},
+		{Name: "body", Type: schema.TYPE_STRING, RawType: "blob"},
+		{Name: "name", Type: schema.TYPE_STRING, Collation: "utf8mb4_general_ci"},
+	}
+	row := []interface{}{"Abc ", "Red ", []byte("Abc "), "Abc", "Abc "}
+	
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		// _bin 区分大小写, PAD SPACE 忽略末尾空格
+		{"code = 'Abc'", true},
+		{"code = 'abc'", false},
+		{"code > 'a'", false},
+		{"code LIKE 'ab%'", false},
+		{"code LIKE 'Ab%'", true},
+		// _0900_ 排序规则为 NO PAD
+		{"tag = 'red'", false},
+		{"tag = 'red '", true},
+		{"tag LIKE 're%'", true},
+		// 二进制字符串按字节比较
+		{"raw = 'Abc'", false},
+		{"raw = 'Abc '", true},
+		{"raw LIKE 'a%'", false},
+		{"body = 'abc'", false},
+		{"name = 'abc'", true},
+		{"name LIKE 'ab%'", true},
+		// 列的排序规则优先于字面量
+		{"'abc' = code", false},
+	}
+	
+	for _, test := range tests {
+		f, err := parseRowFilter(test.filter)
+		if err != nil {
+			t.Fatalf("parseRowFilter(%q): %v", test.filter, err)
+		}
+		
+		value, err := f.evalFilter(f.expr, columns, row)
+		if err != nil {
+			t.Fatalf("evalFilter(%q): %v", test.filter, err)
+		}
+		
+		truth := filterTruth(value)
+		got := truth != nil && *truth
+		if got != test.want {
+			t.Errorf("evalFilter(%q) = %v, want %v", test.filter, got, test.want)
+		}
+	}
+}
+
+func TestParseRowFilterLike(t *testing.T) {
+	f, err := parseRowFilter("name LIKE 'a%' OR note LIKE name OR note LIKE NULL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	// 只有模式为字面量的 LIKE 在解析时编译
+	if len(f.likes) != 1 {
+		t.Errorf("len(likes) = %d, want 1", len(f.likes))
+	}
+	
+	value, err := f.evalFilter(f.expr, filterColumnsFixture, []interface{}{int32(7), "1", "Alice", int64(1), "2024-01-02 03:04:05", "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if truth := filterTruth(value); truth == nil || !*truth {
+		t.Errorf("evalFilter = %v, want true", value)
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"", true},
+		{"id = 7", true},
+		// 结果为 NULL 时不满足
+		{"note = 'x'", false},
+		{"NOT (note = 'x')", false},
+	}
+	
+	for _, test := range tests {
+		s := &conf.Sync{Db: "test", Table: "t", Filter: test.filter}
+		got, err := matchFilter(s, filterColumnsFixture, filterRowFixture)
+		if err != nil {
+			t.Fatalf("matchFilter(%q): %v", test.filter, err)
+		}
+		if got != test.want {
+			t.Errorf("matchFilter(%q) = %v, want %v", test.filter, got, test.want)
+		}
+	}
+}
+
+func TestEvalFilterUnknownColumn(t *testing.T) {
+	f, err := parseRowFilter("missing = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	_, err = f.evalFilter(f.expr, filterColumnsFixture, filterRowFixture)
+	if err == nil {
+		t.Error("evalFilter 引用不存在的列时应当返回错误")
+	}
+}
+
+func TestParseRowFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		// 下推到 MySQL 的 SQL, 为空时表示应当解析失败
+		want string
+	}{
+		{"status = 'active' and id in (1, 2)", "`status`='active' AND `id` IN (1,2)"},
+		{"name like 'a%' or note is null", "`name` LIKE 'a%' OR `note` IS NULL"},
+		{"name = _latin1'a'", "`name`=_LATIN1'a'"},
+		{"price between 1 and 2", "`price` BETWEEN 1 AND 2"},
+		{"id = (select 1)", ""},
+		{"id in (select id from t)", ""},
+		{"t.id = 1", ""},
+		{"upper(name) = 'A'", ""},
+		{"id = 1 order by id", ""},
+		{"id = 1; delete from t", ""},
+	}
+	
+	for _, test := range tests {
+		f, err := parseRowFilter(test.filter)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("parseRowFilter(%q) 应当返回错误, sql: %s", test.filter, f.sql)
+			}
+			continue
+		}
+		
+		if err != nil {
+			t.Fatalf("parseRowFilter(%q): %v", test.filter, err)
+		}
+		if f.sql != test.want {
+			t.Errorf("parseRowFilter(%q).sql = %s, want %s", test.filter, f.sql, test.want)
+		}
+	}
+}
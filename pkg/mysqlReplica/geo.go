@@ -0,0 +1,150 @@
+package mysqlReplica
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"math"
+	"strconv"
+)
+
+// Meilisearch 地理位置保留字段, 支持 _geoRadius / _geoBoundingBox 过滤与 _geoPoint 排序
+// https://www.meilisearch.com/docs/learn/fine_tuning_results/geosearch
+
+const geoField = "_geo"
+
+// WKB 中 POINT 的类型
+
+const wkbPoint = 1
+
+// checkGeo 校验 geo 配置, lat/lng 与 point 二选一
+
+func checkGeo(s *conf.Sync) error {
+	geo := s.GetGeo()
+	if geo == nil {
+		return nil
+	}
+	
+	hasLatLng := geo.Lat != "" || geo.Lng != ""
+	switch {
+	case hasLatLng && geo.Point != "":
+		return fmt.Errorf("%s.%s geo 只能配置 lat/lng 或 point 其中一种", s.Db, s.Table)
+	case hasLatLng && (geo.Lat == "" || geo.Lng == ""):
+		return fmt.Errorf("%s.%s geo 需要同时配置 lat 与 lng", s.Db, s.Table)
+	case !hasLatLng && geo.Point == "":
+		return fmt.Errorf("%s.%s geo 需要配置 lat/lng 或 point", s.Db, s.Table)
+	}
+	return nil
+}
+
+// hasGeo 同步配置是否生成 _geo 字段
+
+func hasGeo(s *conf.Sync) bool {
+	return s.GetGeo() != nil
+}
+
+// buildGeo 按 geo 配置生成 _geo 字段, 值为 NULL 或无法解析时写入 null, 避免更新时保留旧值
+
+func buildGeo(s *conf.Sync, tableColumns []schema.TableColumn, row []interface{}, doc map[string]interface{}) {
+	geo := s.GetGeo()
+	if geo == nil {
+		return
+	}
+	
+	var lat, lng float64
+	var ok bool
+	if geo.Point != "" {
+		lat, lng, ok = decodePoint(columnValue(tableColumns, row, geo.Point), geo.PointLatLng)
+	} else {
+		var latOk, lngOk bool
+		lat, latOk = floatValue(columnValue(tableColumns, row, geo.Lat))
+		lng, lngOk = floatValue(columnValue(tableColumns, row, geo.Lng))
+		ok = latOk && lngOk
+	}
+	
+	if !ok || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		doc[geoField] = nil
+		return
+	}
+	
+	doc[geoField] = map[string]interface{}{
+		"lat": lat,
+		"lng": lng,
+	}
+}
+
+// columnValue 按列名读取行数据, 列不存在时返回 nil
+
+func columnValue(tableColumns []schema.TableColumn, row []interface{}, name string) interface{} {
+	for x, column := range tableColumns {
+		if column.Name == name && x < len(row) {
+			return row[x]
+		}
+	}
+	return nil
+}
+
+func floatValue(value interface{}) (float64, bool) {
+	var f float64
+	switch v := value.(type) {
+	case nil:
+		return 0, false
+	case float32:
+		f = float32Value(v)
+	case float64:
+		f = v
+	case int64:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	default:
+		n, err := strconv.ParseFloat(valueString(v), 64)
+		if err != nil {
+			return 0, false
+		}
+		f = n
+	}
+	
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+
+// decodePoint 解析 MySQL 内部格式的 POINT: 4 字节 SRID (小端) + WKB
+// binlog 与全量读取返回的格式相同; 地理坐标系中 X 为经度、Y 为纬度
+
+func decodePoint(value interface{}, latLng bool) (float64, float64, bool) {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return 0, 0, false
+	}
+	
+	// SRID(4) + 字节序(1) + 类型(4) + X(8) + Y(8)
+	if len(b) != 25 {
+		return 0, 0, false
+	}
+	
+	wkb := b[4:]
+	var order binary.ByteOrder = binary.LittleEndian
+	if wkb[0] == 0 {
+		order = binary.BigEndian
+	}
+	
+	if order.Uint32(wkb[1:5]) != wkbPoint {
+		return 0, 0, false
+	}
+	
+	x := math.Float64frombits(order.Uint64(wkb[5:13]))
+	y := math.Float64frombits(order.Uint64(wkb[13:21]))
+	if latLng {
+		return x, y, true
+	}
+	return y, x, true
+}
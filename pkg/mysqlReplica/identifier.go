@@ -0,0 +1,292 @@
+package mysqlReplica
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"strconv"
+	"strings"
+)
+
+// Meilisearch 文档 id 只允许 a-z A-Z 0-9 - _ , 且长度不超过 511 字节
+// https://www.meilisearch.com/docs/learn/core_concepts/primary_key#formatting-the-document-id
+
+const maxIdentifierLength = 511
+
+// 需要编码的 id 使用的前缀, base64url 的字符都是合法字符
+
+const escapedIdentifierPrefix = "b64_"
+
+// 联合主键默认配置
+
+const (
+	defaultIdField             = "_id"
+	defaultPrimaryKeySeparator = "-"
+)
+
+// 主键列不在表结构中, 或者值为 NULL
+
+var errIdentifierNotFound = errors.New("未匹配 identifier 值")
+
+// keyColumns 返回同步配置中的主键列, primaryKeys 优先于 primaryKey
+
+func keyColumns(s *conf.Sync) []string {
+	if len(s.PrimaryKeys) > 0 {
+		return s.PrimaryKeys
+	}
+	return []string{s.PrimaryKey}
+}
+
+func isCompositeKey(s *conf.Sync) bool {
+	return len(keyColumns(s)) > 1
+}
+
+// documentPrimaryKey 返回 Meilisearch index 的主键字段, 联合主键时为合成的 id 字段
+
+func documentPrimaryKey(s *conf.Sync) string {
+	if !isCompositeKey(s) {
+		return keyColumns(s)[0]
+	}
+	if s.IdField != "" {
+		return s.IdField
+	}
+	return defaultIdField
+}
+
+func primaryKeySeparator(s *conf.Sync) string {
+	if s.PrimaryKeySeparator != "" {
+		return s.PrimaryKeySeparator
+	}
+	return defaultPrimaryKeySeparator
+}
+
+// checkPrimaryKey 校验主键相关配置
+
+func checkPrimaryKey(s *conf.Sync) error {
+	for _, column := range keyColumns(s) {
+		if column == "" {
+			return fmt.Errorf("%s.%s 未配置主键列", s.Db, s.Table)
+		}
+	}
+	
+	if !isCompositeKey(s) {
+		return nil
+	}
+	
+	separator := primaryKeySeparator(s)
+	if strings.Trim(separator, "-") != "" && strings.Trim(separator, "_") != "" {
+		return fmt.Errorf("%s.%s 联合主键分隔符只能由 \"-\" 或 \"_\" 组成: %q", s.Db, s.Table, separator)
+	}
+	
+	for _, column := range keyColumns(s) {
+		if column == documentPrimaryKey(s) {
+			return fmt.Errorf("%s.%s 联合主键 id 字段与表字段重名: %s", s.Db, s.Table, column)
+		}
+	}
+	return nil
+}
+
+// rowIdentifier 从一行数据中找到主键列并转换为 Meilisearch 文档 id
+// insert / update / delete / FirstInitTable 必须使用同一个方法, 否则文档 id 无法对应
+
+func rowIdentifier(tableColumns []schema.TableColumn, row []interface{}, s *conf.Sync) (interface{}, error) {
+	columns := keyColumns(s)
+	if len(columns) == 1 {
+		return columnIdentifier(tableColumns, row, columns[0])
+	}
+	
+	var components []string
+	for _, column := range columns {
+		identifier, err := columnIdentifier(tableColumns, row, column)
+		if err != nil {
+			return nil, fmt.Errorf("联合主键列 %s %w", column, err)
+		}
+		components = append(components, identifierString(identifier))
+	}
+	
+	return compositeIdentifier(components, primaryKeySeparator(s))
+}
+
+func columnIdentifier(tableColumns []schema.TableColumn, row []interface{}, keyColumn string) (interface{}, error) {
+	for x, column := range tableColumns {
+		if column.Name != keyColumn {
+			continue
+		}
+		
+		if x >= len(row) || row[x] == nil {
+			return nil, errIdentifierNotFound
+		}
+		
+		return normalizeIdentifier(column, row[x])
+	}
+	
+	return nil, errIdentifierNotFound
+}
+
+// compositeIdentifier 使用分隔符拼接各主键列的 id
+// 分隔符由 "-" 组成时用 "_" 转义, 由 "_" 组成时用 "-" 转义; 分隔符字符与转义字符本身都会被转义,
+// 保证各列的值中不会出现分隔符, 拼接结果唯一
+
+func compositeIdentifier(components []string, separator string) (interface{}, error) {
+	separatorChar := separator[0]
+	var escapeChar byte = '_'
+	if separatorChar == '_' {
+		escapeChar = '-'
+	}
+	
+	escaped := make([]string, 0, len(components))
+	for _, component := range components {
+		var b strings.Builder
+		for i := 0; i < len(component); i++ {
+			c := component[i]
+			if c == separatorChar || c == escapeChar {
+				fmt.Fprintf(&b, "%c%02x", escapeChar, c)
+				continue
+			}
+			b.WriteByte(c)
+		}
+		escaped = append(escaped, b.String())
+	}
+	
+	id := strings.Join(escaped, separator)
+	if len(id) > maxIdentifierLength {
+		return nil, fmt.Errorf("文档 id 长度超过 %d 字节: %s", maxIdentifierLength, id)
+	}
+	return id, nil
+}
+
+// normalizeIdentifier 将 MySQL 主键值转换为合法的 Meilisearch 文档 id
+// 非负整数保持为整数, 其余类型转换为只包含合法字符的字符串
+// binlog 与 SELECT(文本协议) 返回的 Go 类型不同, 这里需要保证两者转换结果一致
+
+func normalizeIdentifier(column schema.TableColumn, value interface{}) (interface{}, error) {
+	switch column.Type {
+	case schema.TYPE_NUMBER, schema.TYPE_MEDIUM_INT:
+		return integerIdentifier(value)
+	
+	case schema.TYPE_DECIMAL:
+		// 统一去掉小数末尾多余的 0, binlog 与 SELECT 都会返回 decimal 的字符串形式
+		s := strings.TrimSpace(valueString(value))
+		if strings.Contains(s, ".") {
+			s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+		}
+		return stringIdentifier(s)
+	
+	case schema.TYPE_BINARY:
+		b := []byte(valueString(value))
+		// binlog 中 BINARY(n) 会去掉末尾的 0x00, 需要补齐后才能与 SELECT 的结果一致
+		if uint(len(b)) < column.FixedSize {
+			b = append(b, make([]byte, int(column.FixedSize)-len(b))...)
+		}
+		// BINARY(16) 一般用来存放 UUID
+		if column.FixedSize == 16 && len(b) == 16 {
+			return formatUUID(b), nil
+		}
+		return stringIdentifier(hex.EncodeToString(b))
+	
+	default:
+		return stringIdentifier(valueString(value))
+	}
+}
+
+func integerIdentifier(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int8:
+		return signedIdentifier(int64(v)), nil
+	case int16:
+		return signedIdentifier(int64(v)), nil
+	case int32:
+		return signedIdentifier(int64(v)), nil
+	case int64:
+		return signedIdentifier(v), nil
+	case int:
+		return signedIdentifier(int64(v)), nil
+	case uint8:
+		return uint64(v), nil
+	case uint16:
+		return uint64(v), nil
+	case uint32:
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case uint:
+		return uint64(v), nil
+	case string, []byte:
+		s := strings.TrimSpace(valueString(v))
+		if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return u, nil
+		}
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return signedIdentifier(i), nil
+		}
+		return nil, fmt.Errorf("整数主键值格式错误: %q", s)
+	default:
+		return nil, fmt.Errorf("不支持的整数主键类型: %T", value)
+	}
+}
+
+// 负数作为字符串 id 使用 ("-" 为合法字符)
+
+func signedIdentifier(v int64) interface{} {
+	if v < 0 {
+		return strconv.FormatInt(v, 10)
+	}
+	return uint64(v)
+}
+
+func stringIdentifier(s string) (interface{}, error) {
+	if s == "" {
+		return nil, errIdentifierNotFound
+	}
+	
+	id := escapeIdentifier(s)
+	if len(id) > maxIdentifierLength {
+		return nil, fmt.Errorf("文档 id 长度超过 %d 字节: %s", maxIdentifierLength, id)
+	}
+	return id, nil
+}
+
+// escapeIdentifier 合法的 id 保持不变, 其余的值 (包括以 escapedIdentifierPrefix 开头的合法 id)
+// 编码为 escapedIdentifierPrefix + base64url, 两类结果不会重叠, 保证不同的值对应不同的 id
+
+func escapeIdentifier(s string) string {
+	if validIdentifier(s) && !strings.HasPrefix(s, escapedIdentifierPrefix) {
+		return s
+	}
+	return escapedIdentifierPrefix + base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func validIdentifier(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func formatUUID(b []byte) string {
+	s := hex.EncodeToString(b)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// identifierString 删除文档时 id 统一使用字符串形式
+
+func identifierString(id interface{}) string {
+	return fmt.Sprint(id)
+}
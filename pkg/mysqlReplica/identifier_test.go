@@ -0,0 +1,120 @@
+package mysqlReplica
+
+import (
+	"github.com/go-mysql-org/go-mysql/schema"
+	"strings"
+	"testing"
+)
+
+func TestEscapeIdentifier(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"abc-DEF_123", "abc-DEF_123"},
+		{"a b", "b64_YSBi"},
+		{"a__20b", "a__20b"},
+		{"a_5fb", "a_5fb"},
+		{"中文", "b64_5Lit5paH"},
+		// 以前缀开头的合法 id 同样编码, 不会与编码结果重叠
+		{"b64_YSBi", "b64_YjY0X1lTQmk"},
+	}
+	
+	for _, test := range tests {
+		got := escapeIdentifier(test.value)
+		if got != test.want {
+			t.Errorf("escapeIdentifier(%q) = %s, want %s", test.value, got, test.want)
+		}
+		if !validIdentifier(got) {
+			t.Errorf("escapeIdentifier(%q) = %s 包含非法字符", test.value, got)
+		}
+	}
+}
+
+func TestEscapeIdentifierUnique(t *testing.T) {
+	values := []string{"a b", "a__20b", "a_20b", "a-20b", "a_b", "b64_YSBi", "YSBi", "a\x00b", "a/b", "a.b", ""}
+	seen := make(map[string]string)
+	for _, value := range values {
+		id := escapeIdentifier(value)
+		if other, ok := seen[id]; ok {
+			t.Errorf("escapeIdentifier(%q) 与 escapeIdentifier(%q) 相同: %s", value, other, id)
+		}
+		seen[id] = value
+	}
+}
+
+func TestCompositeIdentifier(t *testing.T) {
+	tests := []struct {
+		components []string
+		separator  string
+		want       string
+	}{
+		{[]string{"1", "2"}, "-", "1-2"},
+		{[]string{"a-b", "c"}, "-", "a_2db-c"},
+		{[]string{"a", "b-c"}, "-", "a-b_2dc"},
+		{[]string{"a_b", "c"}, "-", "a_5fb-c"},
+		{[]string{"a_b", "c"}, "_", "a-5fb_c"},
+		{[]string{"a", "b"}, "__", "a__b"},
+	}
+	
+	for _, test := range tests {
+		got, err := compositeIdentifier(test.components, test.separator)
+		if err != nil {
+			t.Fatalf("compositeIdentifier(%q, %q): %v", test.components, test.separator, err)
+		}
+		if got != test.want {
+			t.Errorf("compositeIdentifier(%q, %q) = %v, want %s", test.components, test.separator, got, test.want)
+		}
+	}
+	
+	_, err := compositeIdentifier([]string{strings.Repeat("a", maxIdentifierLength), "b"}, "-")
+	if err == nil {
+		t.Error("compositeIdentifier 超过长度限制时应当返回错误")
+	}
+}
+
+func TestNormalizeIdentifier(t *testing.T) {
+	uuid := []byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	
+	tests := []struct {
+		name   string
+		column schema.TableColumn
+		value  interface{}
+		want   interface{}
+	}{
+		{"int", schema.TableColumn{Type: schema.TYPE_NUMBER}, int32(7), uint64(7)},
+		{"negative int", schema.TableColumn{Type: schema.TYPE_NUMBER}, int64(-7), "-7"},
+		{"int text", schema.TableColumn{Type: schema.TYPE_NUMBER}, "7", uint64(7)},
+		{"uint64", schema.TableColumn{Type: schema.TYPE_NUMBER}, uint64(18446744073709551615), uint64(18446744073709551615)},
+		{"decimal", schema.TableColumn{Type: schema.TYPE_DECIMAL}, "12.500", "b64_MTIuNQ"},
+		{"decimal integer", schema.TableColumn{Type: schema.TYPE_DECIMAL}, "12.000", "12"},
+		{"decimal zero scale", schema.TableColumn{Type: schema.TYPE_DECIMAL}, "120", "120"},
+		{"string", schema.TableColumn{Type: schema.TYPE_STRING}, "abc", "abc"},
+		{"string bytes", schema.TableColumn{Type: schema.TYPE_STRING}, []byte("a b"), "b64_YSBi"},
+		// binlog 中 BINARY(n) 会去掉末尾的 0x00
+		{"binary padding", schema.TableColumn{Type: schema.TYPE_BINARY, FixedSize: 4}, "\x01\x02", "01020000"},
+		{"binary text", schema.TableColumn{Type: schema.TYPE_BINARY, FixedSize: 4}, "\x01\x02\x00\x00", "01020000"},
+		{"uuid", schema.TableColumn{Type: schema.TYPE_BINARY, FixedSize: 16}, string(uuid), "123e4567-e89b-12d3-a456-426614174000"},
+		{"uuid padding", schema.TableColumn{Type: schema.TYPE_BINARY, FixedSize: 16}, string(uuid[:15]), "123e4567-e89b-12d3-a456-426614174000"},
+	}
+	
+	for _, test := range tests {
+		got, err := normalizeIdentifier(test.column, test.value)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: normalizeIdentifier(%q) = %#v, want %#v", test.name, test.value, got, test.want)
+		}
+	}
+	
+	_, err := normalizeIdentifier(schema.TableColumn{Type: schema.TYPE_STRING}, "")
+	if err != errIdentifierNotFound {
+		t.Errorf("空字符串主键应当返回 errIdentifierNotFound, got %v", err)
+	}
+	
+	_, err = normalizeIdentifier(schema.TableColumn{Type: schema.TYPE_NUMBER}, "abc")
+	if err == nil {
+		t.Error("整数主键值格式错误时应当返回错误")
+	}
+}
@@ -0,0 +1,183 @@
+package mysqlReplica
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep JSON 路径中的一级, index >= 0 时为数组下标, 否则为对象字段 key
+
+type jsonPathStep struct {
+	key   string
+	index int
+}
+
+// parseJSONPath 解析 MySQL 风格的 JSON 路径: $.a.b / $.a[0] / $."a b" / $["a"]
+
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("JSON 路径需要以 $ 开头: %s", path)
+	}
+	
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, `"`) {
+				key, n, err := unquoteJSONKey(rest)
+				if err != nil {
+					return nil, fmt.Errorf("JSON 路径 %s: %w", path, err)
+				}
+				steps = append(steps, jsonPathStep{key: key, index: -1})
+				rest = rest[n:]
+				continue
+			}
+			
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("JSON 路径 %s 字段名为空", path)
+			}
+			steps = append(steps, jsonPathStep{key: rest[:end], index: -1})
+			rest = rest[end:]
+		
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("JSON 路径 %s 缺少 ]", path)
+			}
+			
+			inner := strings.TrimSpace(rest[1:end])
+			if strings.HasPrefix(inner, `"`) {
+				key, n, err := unquoteJSONKey(inner)
+				if err != nil || n != len(inner) {
+					return nil, fmt.Errorf("JSON 路径 %s 字段名格式错误", path)
+				}
+				steps = append(steps, jsonPathStep{key: key, index: -1})
+			} else {
+				index, err := strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("JSON 路径 %s 数组下标格式错误: %s", path, inner)
+				}
+				steps = append(steps, jsonPathStep{index: index})
+			}
+			rest = rest[end+1:]
+		
+		default:
+			return nil, fmt.Errorf("JSON 路径 %s 格式错误", path)
+		}
+	}
+	return steps, nil
+}
+
+// unquoteJSONKey 解析开头的双引号字段名, 返回字段名与消耗的长度
+
+func unquoteJSONKey(s string) (string, int, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			key, err := strconv.Unquote(s[:i+1])
+			return key, i + 1, err
+		}
+	}
+	return "", 0, fmt.Errorf("字段名缺少结束的双引号")
+}
+
+// checkJSONPaths 校验 jsonPaths 配置
+
+func checkJSONPaths(s *conf.Sync) error {
+	for _, p := range s.JsonPaths {
+		if p.Column == "" || p.Field == "" {
+			return fmt.Errorf("%s.%s jsonPaths 需要配置 column 与 field", s.Db, s.Table)
+		}
+		
+		_, err := parseJSONPath(p.Path)
+		if err != nil {
+			return fmt.Errorf("%s.%s %w", s.Db, s.Table, err)
+		}
+	}
+	return nil
+}
+
+// decodeJSON 解析 JSON 列的值, 数字保持原样 (json.Number) 避免大整数丢失精度, 格式错误时返回 false
+
+func decodeJSON(value interface{}) (interface{}, bool) {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return nil, false
+	}
+	
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	
+	var decoded interface{}
+	err := decoder.Decode(&decoded)
+	if err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// lookupJSON 按路径读取值, 路径不存在时返回 nil
+
+func lookupJSON(value interface{}, steps []jsonPathStep) interface{} {
+	for _, step := range steps {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if step.index >= 0 {
+				return nil
+			}
+			value = v[step.key]
+		case []interface{}:
+			if step.index < 0 || step.index >= len(v) {
+				return nil
+			}
+			value = v[step.index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// projectJSON 按 jsonPaths 将 JSON 列中的路径提取为文档顶层字段
+// 路径不存在时写入 null, 避免更新时保留旧值
+
+func projectJSON(s *conf.Sync, tableColumns []schema.TableColumn, row []interface{}, doc map[string]interface{}) {
+	decoded := make(map[string]interface{})
+	for _, p := range s.JsonPaths {
+		value, ok := decoded[p.Column]
+		if !ok {
+			for x, column := range tableColumns {
+				if column.Name == p.Column && x < len(row) {
+					value, _ = decodeJSON(row[x])
+					break
+				}
+			}
+			decoded[p.Column] = value
+		}
+		
+		steps, err := parseJSONPath(p.Path)
+		if err != nil {
+			doc[p.Field] = nil
+			continue
+		}
+		doc[p.Field] = lookupJSON(value, steps)
+	}
+}
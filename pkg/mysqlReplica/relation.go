@@ -0,0 +1,405 @@
+package mysqlReplica
+
+import (
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/canal"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// 按关联列读取子表 (或父表) 时每条 SQL 中 IN 的最大值数量
+
+const relationBatchSize = 500
+
+func relationDb(s *conf.Sync, rel *conf.Relation) string {
+	if rel.Db != "" {
+		return rel.Db
+	}
+	return s.Db
+}
+
+func relationField(rel *conf.Relation) string {
+	if rel.Field != "" {
+		return rel.Field
+	}
+	return rel.Table
+}
+
+func relationParentKey(s *conf.Sync, rel *conf.Relation) string {
+	if rel.ParentKey != "" {
+		return rel.ParentKey
+	}
+	return s.PrimaryKey
+}
+
+// relationKey 关联列的值在父表与子表、binlog 与全量读取中类型可能不同, 统一转换为字符串后匹配
+
+func relationKey(value interface{}) string {
+	return fmt.Sprint(literalValue(value))
+}
+
+// checkRelations 校验 relations 配置
+
+func checkRelations(s *conf.Sync) error {
+	fields := make(map[string]bool, len(s.Relations))
+	for _, rel := range s.Relations {
+		if rel.Table == "" || rel.ForeignKey == "" {
+			return fmt.Errorf("%s.%s relations 需要配置 table 与 foreignKey", s.Db, s.Table)
+		}
+		if rel.ParentKey == "" && len(s.PrimaryKeys) > 0 {
+			return fmt.Errorf("%s.%s 使用联合主键时 relations 需要配置 parentKey", s.Db, s.Table)
+		}
+		
+		field := relationField(rel)
+		if fields[field] {
+			return fmt.Errorf("%s.%s relations 字段 %s 重复", s.Db, s.Table, field)
+		}
+		fields[field] = true
+	}
+	return nil
+}
+
+// buildDocs 批量生成文档, 配置了 relations 时按父表的关联列批量读取子表, 写入数组字段
+
+func (eventHandler *EventHandler) buildDocs(executor Executor, tableColumns []schema.TableColumn, rows [][]interface{}, s *conf.Sync) ([]map[string]interface{}, error) {
+	docs := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		doc, err := eventHandler.buildDoc(tableColumns, row, s)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	
+	err := eventHandler.embedRelations(executor, s, tableColumns, rows, docs)
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// embedRelations 读取每个父行的子表数据写入对应文档, 没有子表数据时写入空数组
+
+func (eventHandler *EventHandler) embedRelations(executor Executor, s *conf.Sync, tableColumns []schema.TableColumn, rows [][]interface{}, docs []map[string]interface{}) error {
+	for _, rel := range s.Relations {
+		parentKey := relationParentKey(s, rel)
+		keyIndex := -1
+		for x, column := range tableColumns {
+			if column.Name == parentKey {
+				keyIndex = x
+				break
+			}
+		}
+		if keyIndex < 0 {
+			return fmt.Errorf("%s.%s 关联列 %s 不在表结构中", s.Db, s.Table, parentKey)
+		}
+		
+		seen := make(map[string]bool)
+		var literals []string
+		for _, row := range rows {
+			if keyIndex >= len(row) || row[keyIndex] == nil {
+				continue
+			}
+			
+			key := relationKey(row[keyIndex])
+			if !seen[key] {
+				seen[key] = true
+				literals = append(literals, valueLiteral(tableColumns[keyIndex], row[keyIndex]))
+			}
+		}
+		
+		children, err := eventHandler.readChildren(executor, s, rel, literals)
+		if err != nil {
+			return err
+		}
+		
+		field := relationField(rel)
+		for x, row := range rows {
+			items := []interface{}{}
+			if keyIndex < len(row) && row[keyIndex] != nil {
+				if found, ok := children[relationKey(row[keyIndex])]; ok {
+					items = found
+				}
+			}
+			docs[x][field] = items
+		}
+	}
+	return nil
+}
+
+// readChildren 按关联列的值批量读取子表, 返回关联列的值 -> 数组元素, 按子表主键排序
+
+func (eventHandler *EventHandler) readChildren(executor Executor, s *conf.Sync, rel *conf.Relation, literals []string) (map[string][]interface{}, error) {
+	children := make(map[string][]interface{})
+	if len(literals) == 0 {
+		return children, nil
+	}
+	
+	db := relationDb(s, rel)
+	table, err := eventHandler.canal.GetTable(db, rel.Table)
+	if err != nil {
+		return nil, err
+	}
+	
+	columns := table.Columns
+	if len(rel.Columns) > 0 {
+		columns = make([]schema.TableColumn, 0, len(rel.Columns))
+		for _, name := range rel.Columns {
+			x := table.FindColumn(name)
+			if x < 0 {
+				return nil, fmt.Errorf("子表 %s.%s 列 %s 不存在", db, rel.Table, name)
+			}
+			columns = append(columns, table.Columns[x])
+		}
+	}
+	
+	foreignKey := table.FindColumn(rel.ForeignKey)
+	if foreignKey < 0 {
+		return nil, fmt.Errorf("子表 %s.%s 列 %s 不存在", db, rel.Table, rel.ForeignKey)
+	}
+	
+	// 关联列放在最后, 用于分组
+	selected := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		selected = append(selected, quoteName(column.Name))
+	}
+	selected = append(selected, quoteName(rel.ForeignKey))
+	
+	var orderBy []string
+	for _, x := range table.PKColumns {
+		orderBy = append(orderBy, quoteName(table.Columns[x].Name))
+	}
+	
+	for start := 0; start < len(literals); start += relationBatchSize {
+		end := start + relationBatchSize
+		if end > len(literals) {
+			end = len(literals)
+		}
+		
+		sql := fmt.Sprintf("SELECT %s FROM %s.%s WHERE %s IN (%s)",
+			strings.Join(selected, ", "), quoteName(db), quoteName(rel.Table), quoteName(rel.ForeignKey), strings.Join(literals[start:end], ", "))
+		if len(orderBy) > 0 {
+			sql += " ORDER BY " + strings.Join(orderBy, ", ")
+		}
+		
+		r, err := executor.Execute(sql)
+		if err != nil {
+			return nil, err
+		}
+		
+		for _, v := range r.Values {
+			item := make(map[string]interface{}, len(columns))
+			for x, column := range columns {
+				value, ok := convertColumn(column, v[x].Value(), convertAuto)
+				if ok {
+					item[column.Name] = value
+				}
+			}
+			
+			key := relationKey(v[len(columns)].Value())
+			children[key] = append(children[key], item)
+		}
+	}
+	return children, nil
+}
+
+// relationExecutor 返回 binlog 同步期间读取子表使用的连接, 与 binlog 同步使用的连接分开, 只在 canal 协程中访问
+
+func (eventHandler *EventHandler) relationExecutor() (Executor, error) {
+	if eventHandler.relationConn == nil {
+		config := eventHandler.canalConfig
+		snapshot, err := OpenSnapshot(config.Addr, config.User, config.Password, config.Flavor, SnapshotModeNone, 1, eventHandler.logger)
+		if err != nil {
+			return nil, err
+		}
+		eventHandler.relationConn = snapshot
+	}
+	return eventHandler.relationConn.Executors()[0], nil
+}
+
+// closeRelationExecutor 读取失败时关闭连接, 下一次重新连接
+
+func (eventHandler *EventHandler) closeRelationExecutor() {
+	if eventHandler.relationConn != nil {
+		_ = eventHandler.relationConn.Close()
+		eventHandler.relationConn = nil
+	}
+}
+
+// embedStreamRelations binlog 同步时为父表的文档读取子表数据
+// 读取的是当前的子表数据, 重放 binlog 时之后的子表变更会再次更新父表文档
+
+func (eventHandler *EventHandler) embedStreamRelations(s *conf.Sync, tableColumns []schema.TableColumn, rows [][]interface{}, docs []map[string]interface{}) error {
+	if len(s.Relations) == 0 || len(docs) == 0 {
+		return nil
+	}
+	
+	executor, err := eventHandler.relationExecutor()
+	if err != nil {
+		return err
+	}
+	
+	err = eventHandler.embedRelations(executor, s, tableColumns, rows, docs)
+	if err != nil {
+		eventHandler.closeRelationExecutor()
+		return err
+	}
+	return nil
+}
+
+// onChildRows 子表变更时重新读取受影响的父行与其子表数据, 更新父表文档
+
+func (eventHandler *EventHandler) onChildRows(e *canal.RowsEvent) error {
+	for _, s := range eventHandler.sync {
+		for _, rel := range s.Relations {
+			if relationDb(s, rel) != e.Table.Schema || rel.Table != e.Table.Name {
+				continue
+			}
+			
+			// 行数据按维护的子表结构解析, 重放 ALTER 之前的行时 canal 的表结构与之不一致
+			table, err := eventHandler.childTable(e)
+			if err != nil {
+				return err
+			}
+			
+			foreignKey := table.FindColumn(rel.ForeignKey)
+			if foreignKey < 0 {
+				return fmt.Errorf("子表 %s.%s 列 %s 不存在", e.Table.Schema, e.Table.Name, rel.ForeignKey)
+			}
+			
+			// update 事件中包含更新前后的行, 关联列变化时新旧父行都需要更新
+			seen := make(map[string]bool)
+			var literals []string
+			for _, row := range e.Rows {
+				if foreignKey >= len(row) || row[foreignKey] == nil {
+					continue
+				}
+				
+				key := relationKey(row[foreignKey])
+				if !seen[key] {
+					seen[key] = true
+					literals = append(literals, valueLiteral(table.Columns[foreignKey], row[foreignKey]))
+				}
+			}
+			
+			err = eventHandler.refreshParents(s, rel, literals)
+			if err != nil {
+				eventHandler.closeRelationExecutor()
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// refreshParents 按关联列读取父行, 重新生成文档 (包括所有子表数组) 并写入
+
+func (eventHandler *EventHandler) refreshParents(s *conf.Sync, rel *conf.Relation, literals []string) error {
+	if len(literals) == 0 {
+		return nil
+	}
+	
+	executor, err := eventHandler.relationExecutor()
+	if err != nil {
+		return err
+	}
+	
+	table, err := eventHandler.canal.GetTable(s.Db, s.Table)
+	if err != nil {
+		return err
+	}
+	columns := snapshotColumns(table, s)
+	
+	selected := make([]string, 0, len(columns))
+	for _, column := range columns {
+		selected = append(selected, quoteName(column.Name))
+	}
+	
+	filter, err := filterSQL(s)
+	if err != nil {
+		return err
+	}
+	if filter != "" {
+		filter = " AND (" + filter + ")"
+	}
+	
+	var rows [][]interface{}
+	for start := 0; start < len(literals); start += relationBatchSize {
+		end := start + relationBatchSize
+		if end > len(literals) {
+			end = len(literals)
+		}
+		
+		sql := fmt.Sprintf("SELECT %s FROM %s.%s WHERE %s IN (%s)%s",
+			strings.Join(selected, ", "), quoteName(s.Db), quoteName(s.Table), quoteName(relationParentKey(s, rel)), strings.Join(literals[start:end], ", "), filter)
+		r, err := executor.Execute(sql)
+		if err != nil {
+			return err
+		}
+		
+		for _, v := range r.Values {
+			row := make([]interface{}, len(v))
+			for n, x := range v {
+				row[n] = x.Value()
+			}
+			
+			// 软删除的父行没有文档 (tombstone 模式下保留)
+			indexed, err := rowIndexed(s, columns, row)
+			if err != nil {
+				return err
+			}
+			if indexed {
+				rows = append(rows, row)
+			}
+		}
+	}
+	
+	if len(rows) == 0 {
+		return nil
+	}
+	
+	docs, err := eventHandler.buildDocs(executor, columns, rows, s)
+	if err != nil {
+		return err
+	}
+	
+	primaryKey := documentPrimaryKey(s)
+	
+	// 与父表自身的变更相同, 同时写入 resync 的影子 index, 并记录到 backfill 窗口
+	eventHandler.resyncLock.Lock()
+	defer eventHandler.resyncLock.Unlock()
+	
+	indexes := []string{s.Index}
+	job := eventHandler.resyncs[s.Index]
+	if job != nil && job.active {
+		indexes = append(indexes, job.shadow)
+	}
+	
+	window := eventHandler.backfillWindow(s)
+	for x, doc := range docs {
+		window.observe(doc[primaryKey])
+		
+		err = job.markDirty(s, columns, rows[x])
+		if err != nil {
+			return err
+		}
+	}
+	
+	eventHandler.logger.Info(
+		"子表变更, 更新父表文档",
+		zap.String("database", s.Db),
+		zap.String("table", s.Table),
+		zap.String("relation", fmt.Sprintf("%s.%s", relationDb(s, rel), rel.Table)),
+		zap.Int("rows", len(docs)),
+	)
+	
+	for _, index := range indexes {
+		err := eventHandler.indexClient(job, index).UpdateDocuments(index, primaryKey, docs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
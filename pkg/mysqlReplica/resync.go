@@ -0,0 +1,370 @@
+package mysqlReplica
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"github.com/qx66/mysql-meilisearch/pkg/meilisearch"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+const (
+	// 影子 index 后缀, resync 时表数据先写入 <index>__rebuild
+	resyncShadowSuffix = "__rebuild"
+	// 按主键重新读取变更行时每批的行数
+	resyncReloadBatchSize = 500
+)
+
+var errResyncCancelled = errors.New("resync 已取消")
+
+// resyncJob 正在重建的 index
+// active 之前只创建影子 index, binlog 变更不写入影子 index
+// 写入影子 index 的任务使用单独的 client, 任务失败时只终止 resync, 不影响同步位置的确认
+
+type resyncJob struct {
+	index  string
+	shadow string
+	client *meilisearch.Client
+	syncs  []*conf.Sync
+	active bool
+	// 同步表被删除时取消, 不再交换 index
+	cancelled bool
+	// binlog 变更过的行, key 为文档 id, value 为主键列的 SQL 字面量
+	dirty map[*conf.Sync]map[string][]string
+}
+
+// indexClient 返回写入 index 使用的 client, 影子 index 使用 resync 单独的 client
+
+func (eventHandler *EventHandler) indexClient(job *resyncJob, index string) *meilisearch.Client {
+	if job != nil && index == job.shadow {
+		return job.client
+	}
+	return eventHandler.meiliSearchClient
+}
+
+// markDirty 记录 binlog 变更过的行, 调用时需要持有 resyncLock
+
+func (job *resyncJob) markDirty(s *conf.Sync, tableColumns []schema.TableColumn, row []interface{}) error {
+	if job == nil || !job.active {
+		return nil
+	}
+	
+	identifier, err := rowIdentifier(tableColumns, row, s)
+	if err != nil {
+		return err
+	}
+	
+	literals, err := keyLiterals(tableColumns, row, s)
+	if err != nil {
+		return err
+	}
+	
+	keys, ok := job.dirty[s]
+	if !ok {
+		keys = make(map[string][]string)
+		job.dirty[s] = keys
+	}
+	keys[identifierString(identifier)] = literals
+	return nil
+}
+
+// takeDirty 取出已记录的变更行, 调用时需要持有 resyncLock
+
+func (job *resyncJob) takeDirty() map[*conf.Sync]map[string][]string {
+	dirty := job.dirty
+	job.dirty = make(map[*conf.Sync]map[string][]string)
+	return dirty
+}
+
+// Resync 在不停止 binlog 同步的情况下重建 index, 任务在后台执行, 返回 nil 只表示任务已经开始
+// 1. 删除残留的影子 index <index>__rebuild, 按 UpdateAttributes 相同的设置重新创建
+// 2. binlog 变更同时写入 index 与影子 index, 并记录变更过的行
+// 3. 将该 index 对应的所有表复制到影子 index
+// 4. 按主键重新读取复制期间变更过的行写入影子 index, 覆盖复制时可能写入的旧数据
+// 5. 原子交换 index 与影子 index, 之后删除影子 index (交换后为旧数据)
+
+func (eventHandler *EventHandler) Resync(index string) error {
+	if eventHandler.canal == nil {
+		return errors.New("canal 未初始化, 无法 resync")
+	}
+	
+	var syncs []*conf.Sync
+	for _, s := range eventHandler.sync {
+		if s.Index == index {
+			syncs = append(syncs, s)
+		}
+	}
+	
+	if len(syncs) == 0 {
+		return fmt.Errorf("index %s 不在同步配置中", index)
+	}
+	
+	eventHandler.resyncLock.Lock()
+	defer eventHandler.resyncLock.Unlock()
+	
+	if _, ok := eventHandler.resyncs[index]; ok {
+		return fmt.Errorf("index %s 正在 resync", index)
+	}
+	
+	job := &resyncJob{
+		index:  index,
+		shadow: index + resyncShadowSuffix,
+		client: eventHandler.meiliSearchClient.Isolated(),
+		syncs:  syncs,
+		dirty:  make(map[*conf.Sync]map[string][]string),
+	}
+	eventHandler.resyncs[index] = job
+	
+	go eventHandler.resync(job)
+	return nil
+}
+
+func (eventHandler *EventHandler) resync(job *resyncJob) {
+	startTime := time.Now()
+	eventHandler.logger.Info(
+		"开始 resync",
+		zap.String("index", job.index),
+		zap.String("shadow", job.shadow),
+	)
+	
+	err := eventHandler.runResync(job)
+	if err != nil {
+		eventHandler.logger.Error(
+			"resync 失败, 删除影子 index",
+			zap.String("index", job.index),
+			zap.String("shadow", job.shadow),
+			zap.Error(err),
+		)
+		
+		// 先停止写入影子 index 再删除, 取消时 job 已经移除, 不能删除之后新发起的 resync
+		eventHandler.resyncLock.Lock()
+		if eventHandler.resyncs[job.index] == job {
+			delete(eventHandler.resyncs, job.index)
+		}
+		eventHandler.resyncLock.Unlock()
+		
+		err = eventHandler.meiliSearchClient.DeleteIndex(eventHandler.ctx, job.shadow)
+		if err != nil {
+			eventHandler.logger.Error(
+				"删除影子 index 失败",
+				zap.String("shadow", job.shadow),
+				zap.Error(err),
+			)
+		}
+		return
+	}
+	
+	eventHandler.logger.Info(
+		"resync 成功",
+		zap.String("index", job.index),
+		zap.Duration("elapsed", time.Since(startTime)),
+	)
+}
+
+func (eventHandler *EventHandler) runResync(job *resyncJob) error {
+	err := eventHandler.meiliSearchClient.DeleteIndex(eventHandler.ctx, job.shadow)
+	if err != nil {
+		return err
+	}
+	
+	for _, s := range job.syncs {
+		err = eventHandler.updateIndexAttributes(job.shadow, s)
+		if err != nil {
+			return err
+		}
+	}
+	
+	eventHandler.resyncLock.Lock()
+	job.active = true
+	eventHandler.resyncLock.Unlock()
+	
+	// 复制使用单独的连接, 不开启一致性快照, 复制期间的变更由第 4 步修正
+	config := eventHandler.canalConfig
+	snapshot, err := OpenSnapshot(config.Addr, config.User, config.Password, config.Flavor, SnapshotModeNone, int(eventHandler.snapshotConf.GetConcurrency()), eventHandler.logger)
+	if err != nil {
+		return err
+	}
+	defer snapshot.Close()
+	
+	executors := snapshot.Executors()
+	snapshots := make(map[*conf.Sync]*TableSnapshot)
+	tasks, err := eventHandler.planCopyTasks(eventHandler.canal, executors[0], len(executors), job.syncs, func(s *conf.Sync) (string, *TableSnapshot) {
+		tableSnapshot, ok := snapshots[s]
+		if !ok {
+			tableSnapshot = &TableSnapshot{}
+			snapshots[s] = tableSnapshot
+		}
+		return job.shadow, tableSnapshot
+	})
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		task.client = job.client
+	}
+	
+	err = eventHandler.runCopyTasks(eventHandler.ctx, executors, tasks, nil)
+	if err != nil {
+		return err
+	}
+	
+	err = job.client.Tracker().Wait(eventHandler.ctx, job.client.Tracker().Take())
+	if err != nil {
+		return err
+	}
+	
+	// 先在不暂停 binlog 同步的情况下重新读取变更过的行, 缩短暂停的时间
+	eventHandler.resyncLock.Lock()
+	dirty := job.takeDirty()
+	eventHandler.resyncLock.Unlock()
+	
+	err = eventHandler.reloadRows(executors[0], job, dirty)
+	if err != nil {
+		return err
+	}
+	
+	// 暂停 binlog 同步, 重新读取剩余的变更行, 确认影子 index 的任务全部成功后提交交换任务, 之后的变更只写入 index
+	eventHandler.resyncLock.Lock()
+	if job.cancelled {
+		eventHandler.resyncLock.Unlock()
+		return errResyncCancelled
+	}
+	err = eventHandler.reloadRows(executors[0], job, job.takeDirty())
+	if err == nil {
+		err = job.client.Tracker().Wait(eventHandler.ctx, job.client.Tracker().Take())
+	}
+	var taskUID int64
+	if err == nil {
+		taskUID, err = eventHandler.meiliSearchClient.SwapIndexes(job.index, job.shadow)
+	}
+	if err == nil {
+		delete(eventHandler.resyncs, job.index)
+	}
+	eventHandler.resyncLock.Unlock()
+	
+	if err != nil {
+		return err
+	}
+	
+	err = eventHandler.meiliSearchClient.WaitTask(eventHandler.ctx, taskUID)
+	if err != nil {
+		return err
+	}
+	
+	return eventHandler.meiliSearchClient.DeleteIndex(eventHandler.ctx, job.shadow)
+}
+
+// reloadRows 按主键重新读取变更过的行写入影子 index, 已经不存在的行删除对应文档
+
+func (eventHandler *EventHandler) reloadRows(executor Executor, job *resyncJob, dirty map[*conf.Sync]map[string][]string) error {
+	for s, keys := range dirty {
+		table, err := eventHandler.canal.GetTable(s.Db, s.Table)
+		if err != nil {
+			return err
+		}
+		
+		columns := snapshotColumns(table, s)
+		selected := make([]string, 0, len(columns))
+		for _, column := range columns {
+			selected = append(selected, quoteName(column.Name))
+		}
+		
+		keyNames := make([]string, 0, len(keyColumns(s)))
+		for _, name := range keyColumns(s) {
+			keyNames = append(keyNames, quoteName(name))
+		}
+		
+		// 与复制时的 chunker 使用相同的条件, 不满足 filter 或者已被软删除的行按已删除处理
+		filter, err := snapshotFilterSQL(s)
+		if err != nil {
+			return err
+		}
+		if filter != "" {
+			filter = " AND (" + filter + ")"
+		}
+		
+		var identifiers []string
+		for identifier := range keys {
+			identifiers = append(identifiers, identifier)
+		}
+		
+		primaryKey := documentPrimaryKey(s)
+		for start := 0; start < len(identifiers); start += resyncReloadBatchSize {
+			end := start + resyncReloadBatchSize
+			if end > len(identifiers) {
+				end = len(identifiers)
+			}
+			
+			tuples := make([]string, 0, end-start)
+			for _, identifier := range identifiers[start:end] {
+				tuples = append(tuples, "("+strings.Join(keys[identifier], ", ")+")")
+			}
+			
+			sql := fmt.Sprintf("SELECT %s FROM %s.%s WHERE (%s) IN (%s)%s",
+				strings.Join(selected, ", "), quoteName(s.Db), quoteName(s.Table), strings.Join(keyNames, ", "), strings.Join(tuples, ", "), filter)
+			r, err := executor.Execute(sql)
+			if err != nil {
+				return err
+			}
+			
+			var rows [][]interface{}
+			for _, v := range r.Values {
+				row := make([]interface{}, len(v))
+				for n, x := range v {
+					row[n] = x.Value()
+				}
+				
+				live, err := rowLive(s, columns, row)
+				if err != nil {
+					return err
+				}
+				if live {
+					rows = append(rows, row)
+				}
+			}
+			
+			docs, err := eventHandler.buildDocs(executor, columns, rows, s)
+			if err != nil {
+				return err
+			}
+			
+			found := make(map[string]bool)
+			for _, doc := range docs {
+				found[identifierString(doc[primaryKey])] = true
+			}
+			
+			var missing []string
+			for _, identifier := range identifiers[start:end] {
+				if !found[identifier] {
+					missing = append(missing, identifier)
+				}
+			}
+			
+			if len(docs) > 0 {
+				err = job.client.CreateDocs(job.shadow, docs, primaryKey)
+				if err != nil {
+					return err
+				}
+			}
+			
+			if len(missing) > 0 {
+				err = job.client.DeleteDocuments(job.shadow, missing)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		
+		eventHandler.logger.Info(
+			"重新读取复制期间变更过的行",
+			zap.String("database", s.Db),
+			zap.String("table", s.Table),
+			zap.String("index", job.shadow),
+			zap.Int("rows", len(identifiers)),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package mysqlReplica
+
+import (
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/canal"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/pingcap/tidb/parser/ast"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// documentField 返回列对应的文档字段名, 按 columnAliases 转换, 单列主键不转换
+
+func documentField(s *conf.Sync, column string) string {
+	if column == documentPrimaryKey(s) {
+		return column
+	}
+	if alias, ok := s.ColumnAliases[column]; ok && alias != "" {
+		return alias
+	}
+	return column
+}
+
+// checkColumns 校验 includeColumns / excludeColumns 配置
+
+func checkColumns(s *conf.Sync) error {
+	if len(s.IncludeColumns) > 0 && len(s.ExcludeColumns) > 0 {
+		return fmt.Errorf("%s.%s includeColumns 与 excludeColumns 只能配置其中一种", s.Db, s.Table)
+	}
+	return nil
+}
+
+// includeColumn 列是否写入文档, 按 includeColumns / excludeColumns 过滤
+// 主键、jsonPaths、geo、vectors 直接读取行数据, 不受过滤影响; 全量读取时由 snapshotColumns 下推到 SELECT 的列
+
+func includeColumn(s *conf.Sync, column string) bool {
+	if len(s.IncludeColumns) > 0 {
+		for _, c := range s.IncludeColumns {
+			if c == column {
+				return true
+			}
+		}
+		return false
+	}
+	
+	for _, c := range s.ExcludeColumns {
+		if c == column {
+			return false
+		}
+	}
+	return true
+}
+
+// rowColumns 返回与行数据对应的表结构列
+// canal 缓存的是当前的表结构, 从旧位置重放 binlog 时 ALTER 之前的行与之不一致,
+// 因此按 binlog 顺序维护同步表的表结构 (随 checkpoint 保存), 列数一致时优先使用
+
+func (eventHandler *EventHandler) rowColumns(s *conf.Sync, e *canal.RowsEvent) ([]schema.TableColumn, error) {
+	width := 0
+	if len(e.Rows) > 0 {
+		width = len(e.Rows[0])
+	}
+	
+	key := fmt.Sprintf("%s.%s", s.Db, s.Table)
+	cached := eventHandler.schemas[key]
+	if cached != nil && len(cached.Columns) == width {
+		return cached.Columns, nil
+	}
+	
+	if len(e.Table.Columns) == width {
+		// 没有处理到对应的 ALTER (例如在线改表工具切换表), 以 canal 的表结构为准
+		err := eventHandler.setSchema(s, cached, e.Table, nil)
+		if err != nil {
+			eventHandler.logger.Warn(
+				"更新 index 设置失败",
+				zap.String("database", s.Db),
+				zap.String("table", s.Table),
+				zap.Error(err),
+			)
+		}
+		return e.Table.Columns, nil
+	}
+	
+	return nil, fmt.Errorf("%s 行数据 %d 列, 表结构 %d 列", key, width, len(e.Table.Columns))
+}
+
+// childTable 返回子表行数据对应的表结构, 与 rowColumns 相同按 binlog 顺序维护子表的表结构
+
+func (eventHandler *EventHandler) childTable(e *canal.RowsEvent) (*schema.Table, error) {
+	width := 0
+	if len(e.Rows) > 0 {
+		width = len(e.Rows[0])
+	}
+	
+	key := fmt.Sprintf("%s.%s", e.Table.Schema, e.Table.Name)
+	cached := eventHandler.schemas[key]
+	if cached != nil && len(cached.Columns) == width {
+		return cached, nil
+	}
+	
+	if len(e.Table.Columns) == width {
+		eventHandler.trackSchema(key, e.Table)
+		return e.Table, nil
+	}
+	
+	return nil, fmt.Errorf("子表 %s 行数据 %d 列, 表结构 %d 列", key, width, len(e.Table.Columns))
+}
+
+// trackSchema 记录表结构, 与之后的位置一起写入 checkpoint
+
+func (eventHandler *EventHandler) trackSchema(key string, t *schema.Table) {
+	eventHandler.schemas[key] = t
+	if eventHandler.pendingSchemas == nil {
+		eventHandler.pendingSchemas = make(map[string]*schema.Table)
+	}
+	eventHandler.pendingSchemas[key] = t
+}
+
+// alterTable ALTER 同步表之后, 按最新的表结构更新缓存与 index 设置
+
+func (eventHandler *EventHandler) alterTable(db, table string, specs []*ast.AlterTableSpec) error {
+	renamed := make(map[string]string)
+	for _, spec := range specs {
+		switch spec.Tp {
+		case ast.AlterTableRenameColumn:
+			renamed[spec.OldColumnName.Name.O] = spec.NewColumnName.Name.O
+		case ast.AlterTableChangeColumn:
+			if len(spec.NewColumns) > 0 && spec.OldColumnName.Name.O != spec.NewColumns[0].Name.Name.O {
+				renamed[spec.OldColumnName.Name.O] = spec.NewColumns[0].Name.Name.O
+			}
+		}
+	}
+	
+	for _, s := range eventHandler.sync {
+		if s.Db != db || s.Table != table {
+			continue
+		}
+		
+		err := checkColumnRenames(s, renamed)
+		if err != nil {
+			return err
+		}
+		
+		// 列改名随位置写入 checkpoint, 重启时按此更新同步配置
+		for from, to := range renamed {
+			eventHandler.columnRenames = append(eventHandler.columnRenames, ColumnRename{
+				Source: eventHandler.sourceTable(s),
+				From:   from,
+				To:     to,
+			})
+		}
+		
+		err = eventHandler.refreshSchema(s, renamed)
+		if err != nil {
+			return err
+		}
+	}
+	
+	eventHandler.refreshChildSchema(db, table)
+	return nil
+}
+
+// refreshChildSchema ALTER 子表之后从 canal 重新读取子表的表结构
+
+func (eventHandler *EventHandler) refreshChildSchema(db, table string) {
+	for _, s := range eventHandler.sync {
+		for _, rel := range s.Relations {
+			if relationDb(s, rel) != db || rel.Table != table {
+				continue
+			}
+			
+			t, err := eventHandler.canal.GetTable(db, table)
+			if err != nil {
+				eventHandler.logger.Warn(
+					"读取子表结构失败",
+					zap.String("database", db),
+					zap.String("table", table),
+					zap.Error(err),
+				)
+				return
+			}
+			
+			eventHandler.trackSchema(fmt.Sprintf("%s.%s", db, table), t)
+			return
+		}
+	}
+}
+
+// refreshSchema 从 canal 重新读取表结构 (canal 处理 DDL 时已清除缓存)
+
+func (eventHandler *EventHandler) refreshSchema(s *conf.Sync, renamed map[string]string) error {
+	t, err := eventHandler.canal.GetTable(s.Db, s.Table)
+	if err != nil {
+		// 重放 binlog 时表可能已经被删除
+		eventHandler.logger.Warn(
+			"读取表结构失败",
+			zap.String("database", s.Db),
+			zap.String("table", s.Table),
+			zap.Error(err),
+		)
+		return nil
+	}
+	
+	key := fmt.Sprintf("%s.%s", s.Db, s.Table)
+	return eventHandler.setSchema(s, eventHandler.schemas[key], t, renamed)
+}
+
+// setSchema 记录表结构, 与之后的位置一起写入 checkpoint
+// 表结构变化时输出变化的列, 并从 filterable 设置中移除已删除的列, 改名的列没有别名时跟随新列名
+
+func (eventHandler *EventHandler) setSchema(s *conf.Sync, old, t *schema.Table, renamed map[string]string) error {
+	eventHandler.trackSchema(fmt.Sprintf("%s.%s", s.Db, s.Table), t)
+	
+	if old == nil {
+		return eventHandler.evolveAttributes(s, nil, renamed)
+	}
+	
+	oldColumns := columnNames(old)
+	newColumns := columnNames(t)
+	current := make(map[string]bool, len(newColumns))
+	for _, name := range newColumns {
+		current[name] = true
+	}
+	
+	previous := make(map[string]bool, len(oldColumns))
+	var dropped []string
+	for _, name := range oldColumns {
+		previous[name] = true
+		if !current[name] && !current[renamed[name]] {
+			dropped = append(dropped, name)
+		}
+	}
+	
+	renamedTo := make(map[string]bool, len(renamed))
+	for _, name := range renamed {
+		renamedTo[name] = true
+	}
+	
+	var added []string
+	for _, name := range newColumns {
+		if !previous[name] && !renamedTo[name] {
+			added = append(added, name)
+		}
+	}
+	
+	eventHandler.logger.Info(
+		"表结构变化",
+		zap.String("database", s.Db),
+		zap.String("table", s.Table),
+		zap.Strings("oldColumns", oldColumns),
+		zap.Strings("newColumns", newColumns),
+		zap.Strings("added", added),
+		zap.Strings("dropped", dropped),
+		zap.Any("renamed", renamed),
+	)
+	
+	return eventHandler.evolveAttributes(s, dropped, renamed)
+}
+
+// evolveAttributes 按列的删除、改名调整同步配置与 filterable 设置, 只处理配置中已有的字段
+
+func (eventHandler *EventHandler) evolveAttributes(s *conf.Sync, dropped []string, renamed map[string]string) error {
+	old := append([]string{}, s.FilterAbleField...)
+	changed := renameSyncColumns(s, renamed)
+	
+	removed := make(map[string]bool, len(dropped))
+	for _, column := range dropped {
+		removed[documentField(s, column)] = true
+	}
+	
+	filterAbleField := make([]string, 0, len(s.FilterAbleField))
+	for _, field := range s.FilterAbleField {
+		if removed[field] {
+			changed = true
+			continue
+		}
+		filterAbleField = append(filterAbleField, field)
+	}
+	
+	if !changed {
+		return nil
+	}
+	
+	eventHandler.logger.Info(
+		"表结构变化, 更新 filterable 设置",
+		zap.String("database", s.Db),
+		zap.String("table", s.Table),
+		zap.String("index", s.Index),
+		zap.Strings("old", old),
+		zap.Strings("new", filterAbleField),
+	)
+	
+	s.FilterAbleField = filterAbleField
+	return eventHandler.updateIndexAttributes(s.Index, s)
+}
+
+// checkColumnRenames 主键列、关联列以及 filter 中引用的列改名后无法继续按原配置同步, 返回错误
+
+func checkColumnRenames(s *conf.Sync, renamed map[string]string) error {
+	if len(renamed) == 0 {
+		return nil
+	}
+	
+	referenced := append([]string{}, keyColumns(s)...)
+	for _, rel := range s.Relations {
+		referenced = append(referenced, relationParentKey(s, rel))
+	}
+	referenced = append(referenced, filterColumns(s.Filter)...)
+	
+	for from := range renamed {
+		for _, column := range referenced {
+			if strings.EqualFold(column, from) {
+				return fmt.Errorf("%s.%s 主键、关联列或 filter 引用的列 %s 被改名为 %s, 需要修改同步配置后重新同步", s.Db, s.Table, from, renamed[from])
+			}
+		}
+	}
+	return nil
+}
+
+// renameSyncColumns 同步配置中引用的列跟随新列名, 有别名的列保留原来的别名 (文档字段不变),
+// 没有别名的列 filterable 设置跟随新列名, 返回 filterable 设置是否变化
+
+func renameSyncColumns(s *conf.Sync, renamed map[string]string) bool {
+	if len(renamed) == 0 {
+		return false
+	}
+	
+	replaced := make(map[string]string, len(renamed))
+	for from, to := range renamed {
+		field := documentField(s, from)
+		if alias, ok := s.ColumnAliases[from]; ok {
+			delete(s.ColumnAliases, from)
+			s.ColumnAliases[to] = alias
+		}
+		if converter, ok := s.Converters[from]; ok {
+			delete(s.Converters, from)
+			s.Converters[to] = converter
+		}
+		if newField := documentField(s, to); newField != field {
+			replaced[field] = newField
+		}
+	}
+	
+	s.IncludeColumns = renameColumns(s.IncludeColumns, renamed)
+	s.ExcludeColumns = renameColumns(s.ExcludeColumns, renamed)
+	for _, jsonPath := range s.JsonPaths {
+		jsonPath.Column = renameColumn(jsonPath.Column, renamed)
+	}
+	if geo := s.GetGeo(); geo != nil {
+		geo.Lat = renameColumn(geo.Lat, renamed)
+		geo.Lng = renameColumn(geo.Lng, renamed)
+		geo.Point = renameColumn(geo.Point, renamed)
+	}
+	for _, v := range s.Vectors {
+		v.Column = renameColumn(v.Column, renamed)
+	}
+	if softDelete := s.GetSoftDelete(); softDelete != nil {
+		softDelete.Column = renameColumn(softDelete.Column, renamed)
+	}
+	
+	changed := false
+	for x, field := range s.FilterAbleField {
+		if to, ok := replaced[field]; ok {
+			s.FilterAbleField[x] = to
+			changed = true
+		}
+	}
+	return changed
+}
+
+func renameColumn(column string, renamed map[string]string) string {
+	if to, ok := renamed[column]; ok {
+		return to
+	}
+	return column
+}
+
+func renameColumns(columns []string, renamed map[string]string) []string {
+	if len(columns) == 0 || len(renamed) == 0 {
+		return columns
+	}
+	
+	result := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if to, ok := renamed[column]; ok {
+			column = to
+		}
+		result = append(result, column)
+	}
+	return result
+}
+
+func columnNames(t *schema.Table) []string {
+	names := make([]string, 0, len(t.Columns))
+	for _, column := range t.Columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
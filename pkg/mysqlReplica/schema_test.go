@@ -0,0 +1,87 @@
+package mysqlReplica
+
+import (
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"reflect"
+	"testing"
+)
+
+func TestRenameSyncColumns(t *testing.T) {
+	s := &conf.Sync{
+		Db:              "test",
+		Table:           "t",
+		PrimaryKey:      "id",
+		FilterAbleField: []string{"title", "cat", "state"},
+		IncludeColumns:  []string{"id", "title", "category", "state", "flag"},
+		ColumnAliases:   map[string]string{"category": "cat"},
+		Converters:      map[string]string{"state": "string"},
+		SoftDelete:      &conf.SoftDelete{Column: "flag"},
+	}
+	
+	changed := renameSyncColumns(s, map[string]string{"title": "name", "category": "kind", "state": "status", "flag": "deleted"})
+	if !changed {
+		t.Error("没有别名的 filterable 列改名后 filterable 设置应当变化")
+	}
+	
+	// 有别名的列保留原来的文档字段
+	if want := []string{"name", "cat", "status"}; !reflect.DeepEqual(s.FilterAbleField, want) {
+		t.Errorf("FilterAbleField = %v, want %v", s.FilterAbleField, want)
+	}
+	if want := []string{"id", "name", "kind", "status", "deleted"}; !reflect.DeepEqual(s.IncludeColumns, want) {
+		t.Errorf("IncludeColumns = %v, want %v", s.IncludeColumns, want)
+	}
+	if want := map[string]string{"kind": "cat"}; !reflect.DeepEqual(s.ColumnAliases, want) {
+		t.Errorf("ColumnAliases = %v, want %v", s.ColumnAliases, want)
+	}
+	if want := map[string]string{"status": "string"}; !reflect.DeepEqual(s.Converters, want) {
+		t.Errorf("Converters = %v, want %v", s.Converters, want)
+	}
+	if s.SoftDelete.Column != "deleted" {
+		t.Errorf("SoftDelete.Column = %s, want deleted", s.SoftDelete.Column)
+	}
+}
+
+func TestCheckColumnRenames(t *testing.T) {
+	s := &conf.Sync{
+		Db:         "test",
+		Table:      "t",
+		PrimaryKey: "id",
+		Filter:     "status = 'active'",
+		Relations:  []*conf.Relation{{Table: "child", ParentKey: "code"}},
+	}
+	
+	tests := []struct {
+		renamed map[string]string
+		wantErr bool
+	}{
+		{map[string]string{"title": "name"}, false},
+		{map[string]string{"id": "uid"}, true},
+		{map[string]string{"Status": "state"}, true},
+		{map[string]string{"code": "sku"}, true},
+	}
+	
+	for _, test := range tests {
+		err := checkColumnRenames(s, test.renamed)
+		if (err != nil) != test.wantErr {
+			t.Errorf("checkColumnRenames(%v) = %v, wantErr %v", test.renamed, err, test.wantErr)
+		}
+	}
+}
+
+func TestApplyColumnRename(t *testing.T) {
+	checkpoint := NewCheckpoint("mysql", "")
+	checkpoint.applyColumnRename(ColumnRename{Source: "test.t", From: "a", To: "b"})
+	checkpoint.applyColumnRename(ColumnRename{Source: "test.t", From: "b", To: "c"})
+	checkpoint.applyColumnRename(ColumnRename{Source: "test.t", From: "x", To: "y"})
+	
+	if want := map[string]string{"a": "c", "x": "y"}; !reflect.DeepEqual(checkpoint.ColumnRenames["test.t"], want) {
+		t.Errorf("ColumnRenames = %v, want %v", checkpoint.ColumnRenames["test.t"], want)
+	}
+	
+	// 改回原列名时不再记录
+	checkpoint.applyColumnRename(ColumnRename{Source: "test.t", From: "c", To: "a"})
+	checkpoint.applyColumnRename(ColumnRename{Source: "test.t", From: "y", To: "x"})
+	if _, ok := checkpoint.ColumnRenames["test.t"]; ok {
+		t.Errorf("ColumnRenames = %v, want empty", checkpoint.ColumnRenames)
+	}
+}
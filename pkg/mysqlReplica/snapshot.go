@@ -0,0 +1,267 @@
+package mysqlReplica
+
+import (
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/client"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// 全量同步方式
+
+const (
+	SnapshotModeNone       = ""
+	SnapshotModeConsistent = "consistent"
+	SnapshotModeLock       = "lock"
+)
+
+// 读取表数据的连接使用 utf8mb4, go-mysql 默认的 utf8 (utf8mb3) 会将 4 字节字符返回为 ?
+
+const connCharset = "utf8mb4"
+
+// Executor 执行 SQL, *canal.Canal 与 *client.Conn 都实现了该接口
+
+type Executor interface {
+	Execute(command string, args ...interface{}) (*mysql.Result, error)
+}
+
+// Snapshot 全量同步使用的连接, 与 binlog 同步使用的连接分开
+// 每个 worker 独占一个连接; consistent/lock 模式下每个连接都在一致性快照事务中, 读取的表数据与 Position/GTIDSet 对应
+
+type Snapshot struct {
+	conns    []*client.Conn
+	mode     string
+	Position mysql.Position
+	GTIDSet  string
+}
+
+// OpenSnapshot 打开 concurrency 个连接并获取全量同步起始位置
+// 空 (默认): 不开启事务, 直接读取 binlog 位置, 之后读取表数据期间的变更会在之后重放
+// lock: FLUSH TABLES WITH READ LOCK 期间在所有连接上开启事务并读取 binlog 位置, 位置与快照完全一致
+// consistent: MariaDB 通过 binlog_snapshot_file/binlog_snapshot_position 获取与第一个快照一致的位置;
+// MySQL 无法在不加锁的情况下获取快照对应的位置, 只能在开启事务前获取位置, 快照期间的变更会在之后重放
+
+func OpenSnapshot(addr, user, password, flavor, mode string, concurrency int, logger *zap.Logger) (*Snapshot, error) {
+	if mode != SnapshotModeNone && mode != SnapshotModeConsistent && mode != SnapshotModeLock {
+		return nil, fmt.Errorf("不支持的全量同步方式: %s", mode)
+	}
+	
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	
+	snapshot := &Snapshot{mode: mode}
+	for i := 0; i < concurrency; i++ {
+		conn, err := client.Connect(addr, user, password, "")
+		if err != nil {
+			snapshot.closeConns()
+			return nil, err
+		}
+		snapshot.conns = append(snapshot.conns, conn)
+		
+		err = conn.SetCharset(connCharset)
+		if err != nil {
+			snapshot.closeConns()
+			return nil, err
+		}
+		
+		// TIMESTAMP 按 UTC 返回, 与 binlog 解析时的格式一致
+		_, err = conn.Execute("SET time_zone = '+00:00'")
+		if err != nil {
+			snapshot.closeConns()
+			return nil, err
+		}
+	}
+	
+	err := snapshot.open(flavor, logger)
+	if err != nil {
+		snapshot.closeConns()
+		return nil, err
+	}
+	
+	logger.Info(
+		"开启全量同步连接",
+		zap.String("mode", mode),
+		zap.Int("concurrency", concurrency),
+		zap.String("Position", snapshot.Position.String()),
+		zap.String("GTIDSet", snapshot.GTIDSet),
+	)
+	return snapshot, nil
+}
+
+func (snapshot *Snapshot) open(flavor string, logger *zap.Logger) error {
+	first := snapshot.conns[0]
+	
+	if snapshot.mode == SnapshotModeNone {
+		return snapshot.readMasterStatus(first, flavor)
+	}
+	
+	for _, conn := range snapshot.conns {
+		_, err := conn.Execute("SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ")
+		if err != nil {
+			return err
+		}
+	}
+	
+	if snapshot.mode == SnapshotModeLock {
+		_, err := first.Execute("FLUSH TABLES WITH READ LOCK")
+		if err != nil {
+			return err
+		}
+		
+		err = snapshot.startTransactions(snapshot.conns)
+		if err == nil {
+			err = snapshot.readMasterStatus(first, flavor)
+		}
+		
+		_, unlockErr := first.Execute("UNLOCK TABLES")
+		if err != nil {
+			return err
+		}
+		return unlockErr
+	}
+	
+	// consistent 模式下各连接的快照时间不同, 使用第一个快照的位置, 之后的变更都会在 binlog 中重放
+	if flavor == mysql.MariaDBFlavor {
+		err := snapshot.startTransactions(snapshot.conns[:1])
+		if err != nil {
+			return err
+		}
+		
+		err = snapshot.readMariadbSnapshotStatus(first)
+		if err != nil {
+			return err
+		}
+		return snapshot.startTransactions(snapshot.conns[1:])
+	}
+	
+	logger.Warn("MySQL consistent 模式下 binlog 位置早于快照, 快照期间的变更会在之后重放, 如需完全一致请使用 lock 模式")
+	err := snapshot.readMasterStatus(first, flavor)
+	if err != nil {
+		return err
+	}
+	return snapshot.startTransactions(snapshot.conns)
+}
+
+func (snapshot *Snapshot) startTransactions(conns []*client.Conn) error {
+	for _, conn := range conns {
+		_, err := conn.Execute("START TRANSACTION WITH CONSISTENT SNAPSHOT")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readMasterStatus 读取 binlog 位置与对应的 GTIDSet
+// MySQL 从同一行的 Executed_Gtid_Set 读取, 未开启 GTID 时为空; MariaDB 按位置换算, 与位置保持一致
+
+func (snapshot *Snapshot) readMasterStatus(conn *client.Conn, flavor string) error {
+	r, err := conn.Execute("SHOW MASTER STATUS")
+	if err != nil {
+		return err
+	}
+	
+	if r.RowNumber() == 0 {
+		return fmt.Errorf("SHOW MASTER STATUS 没有返回结果, 请检查是否开启 binlog")
+	}
+	
+	name, _ := r.GetString(0, 0)
+	pos, _ := r.GetUint(0, 1)
+	snapshot.Position = mysql.Position{Name: name, Pos: uint32(pos)}
+	
+	if flavor == mysql.MariaDBFlavor {
+		r, err = conn.Execute("SELECT BINLOG_GTID_POS(?, ?)", snapshot.Position.Name, snapshot.Position.Pos)
+		if err != nil {
+			return err
+		}
+		snapshot.GTIDSet, err = r.GetString(0, 0)
+		return err
+	}
+	
+	// 旧版本 MySQL 没有 Executed_Gtid_Set 列; 多个 UUID 时以换行分隔
+	gtidSet, err := r.GetStringByName(0, "Executed_Gtid_Set")
+	if err == nil {
+		snapshot.GTIDSet = strings.ReplaceAll(gtidSet, "\n", "")
+	}
+	return nil
+}
+
+// MariaDB 在 START TRANSACTION WITH CONSISTENT SNAPSHOT 之后可以读取与快照一致的 binlog 位置
+
+func (snapshot *Snapshot) readMariadbSnapshotStatus(conn *client.Conn) error {
+	r, err := conn.Execute("SHOW STATUS LIKE 'binlog_snapshot_%'")
+	if err != nil {
+		return err
+	}
+	
+	for i := 0; i < r.RowNumber(); i++ {
+		name, _ := r.GetString(i, 0)
+		value, _ := r.GetString(i, 1)
+		switch name {
+		case "Binlog_snapshot_file":
+			snapshot.Position.Name = value
+		case "Binlog_snapshot_position":
+			var pos uint32
+			_, err = fmt.Sscan(value, &pos)
+			if err != nil {
+				return err
+			}
+			snapshot.Position.Pos = pos
+		}
+	}
+	
+	if snapshot.Position.Name == "" {
+		return fmt.Errorf("无法获取 binlog_snapshot_file, 请检查是否开启 binlog")
+	}
+	
+	r, err = conn.Execute("SELECT BINLOG_GTID_POS(?, ?)", snapshot.Position.Name, snapshot.Position.Pos)
+	if err != nil {
+		return err
+	}
+	snapshot.GTIDSet, err = r.GetString(0, 0)
+	return err
+}
+
+// Executors 返回每个 worker 使用的连接
+
+func (snapshot *Snapshot) Executors() []Executor {
+	executors := make([]Executor, 0, len(snapshot.conns))
+	for _, conn := range snapshot.conns {
+		executors = append(executors, conn)
+	}
+	return executors
+}
+
+// Close 结束快照事务并关闭连接
+
+func (snapshot *Snapshot) Close() error {
+	var err error
+	if snapshot.mode != SnapshotModeNone {
+		for _, conn := range snapshot.conns {
+			_, commitErr := conn.Execute("COMMIT")
+			if err == nil {
+				err = commitErr
+			}
+		}
+	}
+	
+	closeErr := snapshot.closeConns()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func (snapshot *Snapshot) closeConns() error {
+	var err error
+	for _, conn := range snapshot.conns {
+		closeErr := conn.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}
+	snapshot.conns = nil
+	return err
+}
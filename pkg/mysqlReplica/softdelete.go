@@ -0,0 +1,159 @@
+package mysqlReplica
+
+import (
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"strconv"
+)
+
+// 行被软删除时的处理方式
+// delete: 删除文档; tombstone: 保留文档, 标记字段为 true (未删除的行为 false), 可以按标记字段过滤
+
+const (
+	softDeleteModeDelete    = "delete"
+	softDeleteModeTombstone = "tombstone"
+)
+
+const defaultTombstoneField = "_deleted"
+
+// checkSoftDelete 校验 softDelete 配置, 并解析已删除的条件
+
+func checkSoftDelete(s *conf.Sync) error {
+	softDelete := s.GetSoftDelete()
+	if softDelete == nil {
+		return nil
+	}
+	
+	if softDelete.Column == "" {
+		return fmt.Errorf("%s.%s softDelete 需要配置 column", s.Db, s.Table)
+	}
+	
+	switch softDelete.Mode {
+	case "", softDeleteModeDelete, softDeleteModeTombstone:
+	default:
+		return fmt.Errorf("%s.%s 未知的 softDelete mode: %s", s.Db, s.Table, softDelete.Mode)
+	}
+	
+	_, err := parseRowFilter(softDeleteSQL(softDelete))
+	if err != nil {
+		return fmt.Errorf("%s.%s softDelete %w", s.Db, s.Table, err)
+	}
+	return nil
+}
+
+// softDeleteSQL 已删除的条件, 使用 <=> 避免 NULL 参与比较时结果为 NULL
+// 数字按数字字面量比较, 兼容 TINYINT / BIT 类型的标记列
+
+func softDeleteSQL(softDelete *conf.SoftDelete) string {
+	if softDelete.Value == "" {
+		return fmt.Sprintf("%s IS NOT NULL", quoteName(softDelete.Column))
+	}
+	
+	value := fmt.Sprintf("'%s'", mysql.Escape(softDelete.Value))
+	if _, err := strconv.ParseInt(softDelete.Value, 10, 64); err == nil {
+		value = softDelete.Value
+	}
+	return fmt.Sprintf("%s <=> %s", quoteName(softDelete.Column), value)
+}
+
+// isTombstone 软删除的行是否保留文档
+
+func isTombstone(s *conf.Sync) bool {
+	return s.GetSoftDelete() != nil && s.GetSoftDelete().Mode == softDeleteModeTombstone
+}
+
+// tombstoneField 返回 tombstone 模式下的标记字段名, 其它情况为空
+
+func tombstoneField(s *conf.Sync) string {
+	if !isTombstone(s) {
+		return ""
+	}
+	if s.GetSoftDelete().TombstoneField != "" {
+		return s.GetSoftDelete().TombstoneField
+	}
+	return defaultTombstoneField
+}
+
+// softDeleted 行是否已被软删除, 没有配置 softDelete 时为 false
+
+func softDeleted(s *conf.Sync, tableColumns []schema.TableColumn, row []interface{}) (bool, error) {
+	softDelete := s.GetSoftDelete()
+	if softDelete == nil {
+		return false, nil
+	}
+	
+	f, err := parseRowFilter(softDeleteSQL(softDelete))
+	if err != nil {
+		return false, err
+	}
+	
+	value, err := f.evalFilter(f.expr, tableColumns, row)
+	if err != nil {
+		return false, fmt.Errorf("%s.%s softDelete: %w", s.Db, s.Table, err)
+	}
+	
+	truth := filterTruth(value)
+	return truth != nil && *truth, nil
+}
+
+// snapshotFilterSQL 全量读取下推的 WHERE 条件: 满足 filter 并且没有被软删除
+
+func snapshotFilterSQL(s *conf.Sync) (string, error) {
+	filter, err := filterSQL(s)
+	if err != nil {
+		return "", err
+	}
+	
+	softDelete := s.GetSoftDelete()
+	if softDelete == nil {
+		return filter, nil
+	}
+	
+	live := fmt.Sprintf("NOT (%s)", softDeleteSQL(softDelete))
+	if filter == "" {
+		return live, nil
+	}
+	return fmt.Sprintf("(%s) AND %s", filter, live), nil
+}
+
+// rowLive 行是否满足 filter 并且没有被软删除, 全量读取只写入这些行
+
+func rowLive(s *conf.Sync, tableColumns []schema.TableColumn, row []interface{}) (bool, error) {
+	matched, err := matchFilter(s, tableColumns, row)
+	if err != nil || !matched {
+		return false, err
+	}
+	
+	deleted, err := softDeleted(s, tableColumns, row)
+	if err != nil {
+		return false, err
+	}
+	return !deleted, nil
+}
+
+// rowIndexed 行是否应当有对应的文档: 满足 filter, 并且没有被软删除或者软删除时保留 tombstone
+
+func rowIndexed(s *conf.Sync, tableColumns []schema.TableColumn, row []interface{}) (bool, error) {
+	if isTombstone(s) {
+		return matchFilter(s, tableColumns, row)
+	}
+	return rowLive(s, tableColumns, row)
+}
+
+// markTombstone tombstone 模式下在文档中写入标记字段
+
+func markTombstone(s *conf.Sync, tableColumns []schema.TableColumn, row []interface{}, doc map[string]interface{}) error {
+	field := tombstoneField(s)
+	if field == "" {
+		return nil
+	}
+	
+	deleted, err := softDeleted(s, tableColumns, row)
+	if err != nil {
+		return err
+	}
+	doc[field] = deleted
+	return nil
+}
@@ -0,0 +1,315 @@
+package mysqlReplica
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/client"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"github.com/qx66/mysql-meilisearch/pkg/meilisearch"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// checkpoint 存储方式
+
+const (
+	CheckpointStoreFile        = "file"
+	CheckpointStoreMysql       = "mysql"
+	CheckpointStoreMeilisearch = "meilisearch"
+)
+
+// checkpoint 存储默认配置
+
+const (
+	defaultCheckpointName   = "default"
+	defaultCheckpointSchema = "mysql_meilisearch"
+	defaultCheckpointTable  = "checkpoint"
+	defaultCheckpointIndex  = "mysql_meilisearch_checkpoint"
+)
+
+// CheckpointStore checkpoint 存储, Load 在没有 checkpoint 时返回 nil
+
+type CheckpointStore interface {
+	Load() (*Checkpoint, error)
+	Save(checkpoint *Checkpoint) error
+	String() string
+}
+
+// NewCheckpointStore 根据 conf.Bootstrap.Checkpoint 创建 checkpoint 存储, 默认使用本地文件
+
+func NewCheckpointStore(bootstrap *conf.Bootstrap, meiliSearchClient *meilisearch.Client, logger *zap.Logger) (CheckpointStore, error) {
+	checkpointConf := bootstrap.Checkpoint
+	if checkpointConf == nil {
+		checkpointConf = &conf.Checkpoint{}
+	}
+	
+	name := checkpointConf.Name
+	if name == "" {
+		name = defaultCheckpointName
+	}
+	
+	switch checkpointConf.Type {
+	case "", CheckpointStoreFile:
+		return NewFileCheckpointStore(bootstrap.Mysql.BinlogCheckpointDir, logger), nil
+	
+	case CheckpointStoreMysql:
+		schema := checkpointConf.Schema
+		if schema == "" {
+			schema = defaultCheckpointSchema
+		}
+		table := checkpointConf.Table
+		if table == "" {
+			table = defaultCheckpointTable
+		}
+		addr := fmt.Sprintf("%s:%d", bootstrap.Mysql.Host, bootstrap.Mysql.Port)
+		return NewMysqlCheckpointStore(addr, bootstrap.Mysql.User, bootstrap.Mysql.Passwd, schema, table, name, logger)
+	
+	case CheckpointStoreMeilisearch:
+		index := checkpointConf.Index
+		if index == "" {
+			index = defaultCheckpointIndex
+		}
+		return NewMeilisearchCheckpointStore(meiliSearchClient, index, name, logger)
+	
+	default:
+		return nil, fmt.Errorf("不支持的 checkpoint 存储方式: %s", checkpointConf.Type)
+	}
+}
+
+// FileCheckpointStore 保存在本地目录中
+
+type FileCheckpointStore struct {
+	dir    string
+	logger *zap.Logger
+}
+
+func NewFileCheckpointStore(dir string, logger *zap.Logger) *FileCheckpointStore {
+	return &FileCheckpointStore{
+		dir:    dir,
+		logger: logger,
+	}
+}
+
+func (store *FileCheckpointStore) Load() (*Checkpoint, error) {
+	return LoadCheckpoint(store.dir, store.logger)
+}
+
+func (store *FileCheckpointStore) Save(checkpoint *Checkpoint) error {
+	return SaveCheckpoint(store.dir, checkpoint)
+}
+
+func (store *FileCheckpointStore) String() string {
+	return fmt.Sprintf("file:%s", store.dir)
+}
+
+// MysqlCheckpointStore 保存在源库中由 mysql-meilisearch 管理的表中
+// 写入 checkpoint 本身也会产生 binlog, EventHandler 会忽略只写入了 checkpoint 表的事务
+
+type MysqlCheckpointStore struct {
+	sync.Mutex
+	addr     string
+	user     string
+	password string
+	schema   string
+	table    string
+	name     string
+	conn     *client.Conn
+	logger   *zap.Logger
+}
+
+func NewMysqlCheckpointStore(addr, user, password, schema, table, name string, logger *zap.Logger) (*MysqlCheckpointStore, error) {
+	store := &MysqlCheckpointStore{
+		addr:     addr,
+		user:     user,
+		password: password,
+		schema:   schema,
+		table:    table,
+		name:     name,
+		logger:   logger,
+	}
+	
+	_, err := store.execute(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`", schema))
+	if err != nil {
+		return nil, err
+	}
+	
+	_, err = store.execute(fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS `%s`.`%s` ("+
+			"`name` VARCHAR(128) NOT NULL PRIMARY KEY, "+
+			"`checkpoint` MEDIUMTEXT NOT NULL, "+
+			"`updated_at` DATETIME NOT NULL"+
+			")", schema, table))
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// execute 执行 SQL, 连接断开时重连一次
+
+func (store *MysqlCheckpointStore) execute(command string, args ...interface{}) (*mysql.Result, error) {
+	store.Lock()
+	defer store.Unlock()
+	
+	var err error
+	for i := 0; i < 2; i++ {
+		if store.conn == nil {
+			store.conn, err = client.Connect(store.addr, store.user, store.password, "")
+			if err != nil {
+				continue
+			}
+			
+			err = store.conn.SetCharset(connCharset)
+			if err != nil {
+				store.conn.Close()
+				store.conn = nil
+				continue
+			}
+		}
+		
+		var r *mysql.Result
+		r, err = store.conn.Execute(command, args...)
+		if err == nil {
+			return r, nil
+		}
+		
+		store.conn.Close()
+		store.conn = nil
+	}
+	return nil, err
+}
+
+func (store *MysqlCheckpointStore) Load() (*Checkpoint, error) {
+	r, err := store.execute(fmt.Sprintf("SELECT `checkpoint` FROM `%s`.`%s` WHERE `name` = ?", store.schema, store.table), store.name)
+	if err != nil {
+		return nil, err
+	}
+	
+	if r.RowNumber() == 0 {
+		return nil, nil
+	}
+	
+	data, err := r.GetString(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	
+	checkpoint, err := parseCheckpoint([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	
+	store.logger.Info(
+		"从 MySQL 中读取同步位置",
+		zap.String("store", store.String()),
+		zap.String("Position", checkpoint.Position().String()),
+		zap.String("GTIDSet", checkpoint.GTIDSet),
+		zap.Time("updatedAt", checkpoint.UpdatedAt),
+	)
+	return checkpoint, nil
+}
+
+func (store *MysqlCheckpointStore) Save(checkpoint *Checkpoint) error {
+	checkpoint.Version = checkpointVersion
+	checkpoint.UpdatedAt = time.Now()
+	
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	
+	_, err = store.execute(fmt.Sprintf(
+		"INSERT INTO `%s`.`%s` (`name`, `checkpoint`, `updated_at`) VALUES (?, ?, NOW()) "+
+			"ON DUPLICATE KEY UPDATE `checkpoint` = VALUES(`checkpoint`), `updated_at` = VALUES(`updated_at`)",
+		store.schema, store.table), store.name, string(data))
+	return err
+}
+
+// IsCheckpointTable 判断是否为 checkpoint 表
+
+func (store *MysqlCheckpointStore) IsCheckpointTable(schema, table string) bool {
+	return schema == store.schema && table == store.table
+}
+
+func (store *MysqlCheckpointStore) String() string {
+	return fmt.Sprintf("mysql:%s.%s/%s", store.schema, store.table, store.name)
+}
+
+// MeilisearchCheckpointStore 以文档形式保存在 Meilisearch 的保留 index 中, checkpoint 与数据保存在一起
+
+type MeilisearchCheckpointStore struct {
+	client *meilisearch.Client
+	index  string
+	name   string
+	logger *zap.Logger
+}
+
+// checkpoint 文档
+
+type checkpointDocument struct {
+	Id         string `json:"id"`
+	Checkpoint string `json:"checkpoint"`
+	UpdatedAt  int64  `json:"updatedAt"`
+}
+
+func NewMeilisearchCheckpointStore(meiliSearchClient *meilisearch.Client, index, name string, logger *zap.Logger) (*MeilisearchCheckpointStore, error) {
+	if !validIdentifier(name) {
+		return nil, fmt.Errorf("checkpoint 名称只能包含 a-z A-Z 0-9 - _: %s", name)
+	}
+	
+	return &MeilisearchCheckpointStore{
+		client: meiliSearchClient,
+		index:  index,
+		name:   name,
+		logger: logger,
+	}, nil
+}
+
+func (store *MeilisearchCheckpointStore) Load() (*Checkpoint, error) {
+	var doc checkpointDocument
+	found, err := store.client.GetDocument(store.index, store.name, &doc)
+	if err != nil {
+		return nil, err
+	}
+	
+	if !found {
+		return nil, nil
+	}
+	
+	checkpoint, err := parseCheckpoint([]byte(doc.Checkpoint))
+	if err != nil {
+		return nil, err
+	}
+	
+	store.logger.Info(
+		"从 Meilisearch 中读取同步位置",
+		zap.String("store", store.String()),
+		zap.String("Position", checkpoint.Position().String()),
+		zap.String("GTIDSet", checkpoint.GTIDSet),
+		zap.Time("updatedAt", checkpoint.UpdatedAt),
+	)
+	return checkpoint, nil
+}
+
+func (store *MeilisearchCheckpointStore) Save(checkpoint *Checkpoint) error {
+	checkpoint.Version = checkpointVersion
+	checkpoint.UpdatedAt = time.Now()
+	
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	
+	doc := checkpointDocument{
+		Id:         store.name,
+		Checkpoint: string(data),
+		UpdatedAt:  checkpoint.UpdatedAt.Unix(),
+	}
+	return store.client.SaveDocument(store.index, "id", doc)
+}
+
+func (store *MeilisearchCheckpointStore) String() string {
+	return fmt.Sprintf("meilisearch:%s/%s", store.index, store.name)
+}
@@ -0,0 +1,181 @@
+package mysqlReplica
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/go-mysql-org/go-mysql/schema"
+	"github.com/qx66/mysql-meilisearch/internal/conf"
+	"github.com/qx66/mysql-meilisearch/pkg/meilisearch"
+	"math"
+)
+
+// Meilisearch 向量保留字段, 文档中为 {"<embedder>": [...]}
+// https://www.meilisearch.com/docs/learn/ai_powered_search/search_with_user_provided_embeddings
+
+const vectorsField = "_vectors"
+
+const defaultEmbedder = "default"
+
+// 向量列格式
+
+const (
+	vectorFormatAuto    = ""
+	vectorFormatJSON    = "json"
+	vectorFormatFloat32 = "float32"
+	vectorFormatFloat64 = "float64"
+)
+
+func embedderName(v *conf.Vector) string {
+	if v.Embedder != "" {
+		return v.Embedder
+	}
+	return defaultEmbedder
+}
+
+// checkVectors 校验 vectors 配置
+
+func checkVectors(s *conf.Sync) error {
+	embedders := make(map[string]bool, len(s.Vectors))
+	for _, v := range s.Vectors {
+		if v.Column == "" || v.Dimensions <= 0 {
+			return fmt.Errorf("%s.%s vectors 需要配置 column 与 dimensions", s.Db, s.Table)
+		}
+		
+		switch v.Format {
+		case vectorFormatAuto, vectorFormatJSON, vectorFormatFloat32, vectorFormatFloat64:
+		default:
+			return fmt.Errorf("%s.%s 列 %s 未知的向量格式: %s", s.Db, s.Table, v.Column, v.Format)
+		}
+		
+		name := embedderName(v)
+		if embedders[name] {
+			return fmt.Errorf("%s.%s embedder %s 重复配置", s.Db, s.Table, name)
+		}
+		embedders[name] = true
+	}
+	return nil
+}
+
+// indexEmbedders 合并写入同一个 index 的所有表的 embedder 设置, 同名 embedder 维度需要一致
+
+func (eventHandler *EventHandler) indexEmbedders(s *conf.Sync) (map[string]meilisearch.Embedder, error) {
+	embedders := make(map[string]meilisearch.Embedder)
+	for _, other := range eventHandler.sync {
+		if other.Index != s.Index {
+			continue
+		}
+		
+		for _, v := range other.Vectors {
+			name := embedderName(v)
+			if e, ok := embedders[name]; ok && e.Dimensions != int(v.Dimensions) {
+				return nil, fmt.Errorf("index %s embedder %s 维度不一致: %d, %d", s.Index, name, e.Dimensions, v.Dimensions)
+			}
+			embedders[name] = meilisearch.Embedder{
+				Source:     meilisearch.EmbedderSourceUserProvided,
+				Dimensions: int(v.Dimensions),
+			}
+		}
+	}
+	return embedders, nil
+}
+
+// isVectorColumn 向量列只写入 _vectors, 不作为普通字段写入文档
+
+func isVectorColumn(s *conf.Sync, column string) bool {
+	for _, v := range s.Vectors {
+		if v.Column == column {
+			return true
+		}
+	}
+	return false
+}
+
+// buildVectors 按 vectors 配置生成 _vectors 字段
+// 列值为 NULL、无法解析或维度不一致时该 embedder 写入 null (文档没有向量), 避免整批文档写入失败
+
+func buildVectors(s *conf.Sync, tableColumns []schema.TableColumn, row []interface{}, doc map[string]interface{}) {
+	if len(s.Vectors) == 0 {
+		return
+	}
+	
+	vectors := make(map[string]interface{}, len(s.Vectors))
+	for _, v := range s.Vectors {
+		var column schema.TableColumn
+		for _, c := range tableColumns {
+			if c.Name == v.Column {
+				column = c
+				break
+			}
+		}
+		
+		vector, ok := decodeVector(column, columnValue(tableColumns, row, v.Column), v.Format)
+		if !ok || len(vector) != int(v.Dimensions) {
+			vectors[embedderName(v)] = nil
+			continue
+		}
+		vectors[embedderName(v)] = vector
+	}
+	doc[vectorsField] = vectors
+}
+
+// decodeVector 解析 JSON 数组或按小端序打包的浮点数, 格式为空时按列类型判断
+
+func decodeVector(column schema.TableColumn, value interface{}, format string) ([]float64, bool) {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil, false
+	}
+	
+	// binlog 中 BINARY(n) 会去掉末尾的 0x00
+	if column.Type == schema.TYPE_BINARY && uint(len(b)) < column.FixedSize {
+		b = append(append([]byte{}, b...), make([]byte, int(column.FixedSize)-len(b))...)
+	}
+	
+	if format == vectorFormatAuto {
+		format = vectorFormatJSON
+		if column.Type == schema.TYPE_BINARY || isBlobColumn(column) {
+			format = vectorFormatFloat32
+		}
+	}
+	
+	var vector []float64
+	switch format {
+	case vectorFormatJSON:
+		err := json.Unmarshal(b, &vector)
+		if err != nil {
+			return nil, false
+		}
+	
+	case vectorFormatFloat32:
+		if len(b)%4 != 0 {
+			return nil, false
+		}
+		for x := 0; x < len(b); x += 4 {
+			vector = append(vector, float64(math.Float32frombits(binary.LittleEndian.Uint32(b[x:]))))
+		}
+	
+	case vectorFormatFloat64:
+		if len(b)%8 != 0 {
+			return nil, false
+		}
+		for x := 0; x < len(b); x += 8 {
+			vector = append(vector, math.Float64frombits(binary.LittleEndian.Uint64(b[x:])))
+		}
+	
+	default:
+		return nil, false
+	}
+	
+	for _, f := range vector {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, false
+		}
+	}
+	return vector, true
+}